@@ -0,0 +1,170 @@
+package harmony
+
+import (
+	gocontext "context"
+	"crypto/tls"
+	"log"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultHost    = "0.0.0.0"
+	defaultTimeout = 60 * time.Second
+
+	defaultShutdownTimeout = 10 * time.Second
+)
+
+type (
+	// Config defines the config for Harmony.
+	Config struct {
+		// Router is the router used to match requests to routes.
+		// Optional. Default value NewRouter().
+		Router Router
+
+		// ShowRoutes prints the route table after the banner when the server starts.
+		// Optional. Default value false.
+		ShowRoutes bool
+
+		// HideBanner hides the banner when the server starts.
+		// Optional. Default value false.
+		HideBanner bool
+
+		// Addr is the TCP address the server listens on, in the form "host:port".
+		// Optional. Default value "0.0.0.0:8080".
+		Addr string
+
+		// ReadTimeout is the maximum duration for reading the entire request, including the body.
+		// Optional. Default value 60s. A negative value means no timeout.
+		ReadTimeout time.Duration
+
+		// ReadHeaderTimeout is the maximum duration for reading the request headers.
+		// Optional. Default value 0, which uses ReadTimeout. A negative value means no timeout.
+		ReadHeaderTimeout time.Duration
+
+		// WriteTimeout is the maximum duration before timing out writes of the response.
+		// Optional. Default value 60s. A negative value means no timeout.
+		WriteTimeout time.Duration
+
+		// IdleTimeout is the maximum duration to wait for the next request on a keep-alive connection.
+		// Optional. Default value 60s. A negative value means no timeout.
+		IdleTimeout time.Duration
+
+		// MaxHeaderBytes is the maximum number of bytes read while parsing the request headers.
+		// Optional. Default value http.DefaultMaxHeaderBytes.
+		MaxHeaderBytes int
+
+		// ErrorLog is the logger for errors accepting connections and unexpected behavior from handlers.
+		// Optional. Default value nil, which logs with the log package's standard logger.
+		ErrorLog *log.Logger
+
+		// BaseContext returns the base context for incoming requests on the listener.
+		// Optional. Default value nil, which uses context.Background.
+		BaseContext func(l net.Listener) gocontext.Context
+
+		// ConnContext modifies the context used for a new connection.
+		// Optional. Default value nil.
+		ConnContext func(ctx gocontext.Context, c net.Conn) gocontext.Context
+
+		// H2C enables HTTP/2 over cleartext connections, with prior knowledge
+		// or the HTTP/1.1 Upgrade: h2c header, for example behind a sidecar
+		// terminating TLS.
+		// Optional. Default value false.
+		H2C bool
+
+		// ProxyProtocol reads the PROXY protocol v1 or v2 header sent by a load
+		// balancer, such as HAProxy or AWS NLB, at the start of every connection,
+		// and reports the client address as the remote address of the request.
+		// Connections without a valid header are closed, so the server must only
+		// be reachable through the load balancer.
+		// Optional. Default value false.
+		ProxyProtocol bool
+
+		// TrustedProxies are the networks of the proxies whose X-Forwarded-For,
+		// Forwarded and X-Real-IP headers are trusted by Context.RealIP.
+		// Optional. Default value nil, which trusts no proxy.
+		TrustedProxies []netip.Prefix
+
+		// TrustUnixSocket trusts the X-Forwarded-For, Forwarded and X-Real-IP
+		// headers of requests received on a Unix socket by ListenUnix, whose
+		// peer is a local proxy allowed by the file mode of the socket.
+		// Optional. Default value false.
+		TrustUnixSocket bool
+
+		// SafeRedirects makes Context.Redirect reject targets on another host
+		// than the one of the request, unless listed in RedirectHosts, so that
+		// a user-provided target cannot send users to a malicious site.
+		// Optional. Default value false.
+		SafeRedirects bool
+
+		// RedirectHosts are the external hosts allowed by SafeRedirects.
+		// "*.example.com" matches any subdomain of example.com.
+		// Optional. Default value nil.
+		RedirectHosts []string
+
+		// CookieKeys are the secret keys of signed and encrypted cookies, each
+		// at least 32 bytes long. New cookies use the first key, while all keys
+		// verify and decrypt, so that a new key can be prepended before the old
+		// one is removed.
+		// Optional. Default value nil, which makes signed and encrypted cookies
+		// fail with ErrNoCookieKeys.
+		CookieKeys [][]byte
+
+		// TLSConfig is the base TLS config used by ListenAndServeTLS.
+		// Optional. Default value nil, which uses TLS 1.2 as minimum version.
+		TLSConfig *tls.Config
+
+		// ClientCAFile is the PEM bundle of the CAs verifying client certificates.
+		// Optional. Default value "", which does not verify client certificates.
+		ClientCAFile string
+
+		// ClientAuth is the policy for client certificates when ClientCAFile is set.
+		// Optional. Default value tls.RequireAndVerifyClientCert.
+		ClientAuth tls.ClientAuthType
+
+		// ShutdownSignals are the signals GracefulShutdown waits for.
+		// Optional. Default value os.Interrupt and syscall.SIGTERM.
+		ShutdownSignals []os.Signal
+
+		// ShutdownTimeout is the maximum duration GracefulShutdown waits for the
+		// new process of a restart to be ready, active connections to drain and
+		// shutdown hooks to run.
+		// Optional. Default value 10s. A negative value means no timeout.
+		ShutdownTimeout time.Duration
+
+		// RestartSignals are the signals on which GracefulShutdown hands the
+		// listener over to a new process before shutting down, such as
+		// syscall.SIGHUP or syscall.SIGUSR2.
+		// Optional. Default value nil, which does not restart.
+		RestartSignals []os.Signal
+	}
+)
+
+// setDefaults sets the default values of the optional fields left empty.
+func (cfg *Config) setDefaults() {
+	if cfg.Router == nil {
+		cfg.Router = NewRouter()
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = net.JoinHostPort(defaultHost, strconv.Itoa(defaultPort))
+	}
+	if cfg.ReadTimeout == 0 {
+		cfg.ReadTimeout = defaultTimeout
+	}
+	if cfg.WriteTimeout == 0 {
+		cfg.WriteTimeout = defaultTimeout
+	}
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = defaultTimeout
+	}
+	if len(cfg.ShutdownSignals) == 0 {
+		cfg.ShutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+}
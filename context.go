@@ -1,11 +1,20 @@
 package harmony
 
 import (
+	"bytes"
+	gocontext "context"
+	"crypto/x509"
 	"encoding/json"
-	"github.com/gorilla/mux"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type (
@@ -14,15 +23,61 @@ type (
 		// Request returns the *http.Request object.
 		Request() *http.Request
 
-		// ResponseWriter returns the http.ResponseWriter object.
+		// Context returns the context of the request, which carries its
+		// deadline, cancellation and values. Unlike Context, which is reused
+		// once the handler returns, it may be kept by goroutines.
+		Context() gocontext.Context
+
+		// SetContext replaces the context of the request.
+		SetContext(ctx gocontext.Context)
+
+		// ResponseWriter returns the http.ResponseWriter object, which is the
+		// *Response returned by Response.
 		ResponseWriter() http.ResponseWriter
 
+		// Response returns the *Response tracking the status, size and committed
+		// state of the response.
+		Response() *Response
+
 		// Bind binds the request body into dest.
 		Bind(dest any) error
 
 		// JSON writes the response in JSON format.
 		JSON(code int, body any) error
 
+		// JSONPretty writes the response in JSON format indented with indent.
+		JSONPretty(code int, body any, indent string) error
+
+		// XML writes the response in XML format.
+		XML(code int, body any) error
+
+		// HTML writes the response in HTML format.
+		HTML(code int, html string) error
+
+		// Blob writes b as the response with the content type.
+		Blob(code int, contentType string, b []byte) error
+
+		// Stream copies r to the response with the content type.
+		Stream(code int, contentType string, r io.Reader) error
+
+		// File serves the file, handling Range requests and conditional GET.
+		File(file string) error
+
+		// FileFS serves the file name of fsys like File.
+		FileFS(fsys fs.FS, name string) error
+
+		// Attachment serves the file to be downloaded and saved as name.
+		Attachment(file, name string) error
+
+		// AttachmentFS serves the file of fsys to be downloaded and saved as name.
+		AttachmentFS(fsys fs.FS, file, name string) error
+
+		// Inline serves the file to be displayed by the browser, named name.
+		Inline(file, name string) error
+
+		// InlineFS serves the file of fsys to be displayed by the browser, named name.
+		InlineFS(fsys fs.FS, file, name string) error
+
 		// PathParams returns the path parameters of the request in map[string]string.
 		PathParams() map[string]string
 
@@ -52,42 +107,117 @@ type (
 		// SendStatus writes the response status code.
 		SendStatus(code int) error
 
+		// NoContent writes the response status code without a body.
+		NoContent(code int) error
+
+		// Redirect redirects the request to url with a 300, 301, 302, 303, 307 or 308 status code.
+		Redirect(code int, url string) error
+
+		// RedirectToRoute redirects the request to the route named name with params.
+		RedirectToRoute(code int, name string, params ...any) error
+
 		// String writes the response in string format.
 		String(code int, body string) error
 
-		// Get returns the value in the context by key.
-		Get(key string) any
+		// URL builds the path of the route named name with params.
+		URL(name string, params ...any) (string, error)
 
-		// Set sets the value in the context by key and value.
-		Set(key string, value any)
+		// PeerCertificate returns the verified client certificate of a TLS request, or nil.
+		PeerCertificate() *x509.Certificate
+
+		// RealIP returns the IP address of the client. The X-Forwarded-For,
+		// Forwarded and X-Real-IP headers are only read from Config.TrustedProxies.
+		RealIP() string
+
+		// Protocol returns the protocol of the request, "HTTP/1.0", "HTTP/1.1"
+		// or "HTTP/2.0" whether HTTP/2 is negotiated with TLS or h2c.
+		Protocol() string
+
+		// Cookie returns the cookie of the request named name, or http.ErrNoCookie.
+		Cookie(name string) (*http.Cookie, error)
+
+		// Cookies returns the cookies of the request.
+		Cookies() []*http.Cookie
+
+		// SetCookie adds the Set-Cookie header of cookie to the response.
+		SetCookie(cookie *http.Cookie)
+
+		// ClearCookie asks the client to delete the cookie named name with path "/".
+		ClearCookie(name string)
+
+		// SignedCookie returns the cookie named name set by SetSignedCookie,
+		// with its verified value, or ErrInvalidCookie.
+		SignedCookie(name string) (*http.Cookie, error)
+
+		// SetSignedCookie sets the cookie with its value signed with HMAC-SHA256,
+		// so that the client can read but not alter it.
+		SetSignedCookie(cookie *http.Cookie) error
+
+		// EncryptedCookie returns the cookie named name set by SetEncryptedCookie,
+		// with its decrypted value, or ErrInvalidCookie.
+		EncryptedCookie(name string) (*http.Cookie, error)
+
+		// SetEncryptedCookie sets the cookie with its value encrypted with
+		// AES-GCM, so that the client can neither read nor alter it.
+		SetEncryptedCookie(cookie *http.Cookie) error
+
+		// Get returns the value in the context by key, which may be of any
+		// comparable type. See GetAs and Key for typed access.
+		Get(key any) any
+
+		// Set sets the value in the context by key and value. It is safe for
+		// concurrent use.
+		Set(key any, value any)
 
 		// reset resets the context.
 		reset()
 
-		// SetResponseWriter sets the http.ResponseWriter.
+		// SetResponseWriter sets the http.ResponseWriter wrapped by Response.
 		SetResponseWriter(w http.ResponseWriter)
 
 		// setRequest sets the *http.Request.
 		setRequest(r *http.Request)
+
+		// pathParams returns the path parameters for the router to fill.
+		pathParams() *Params
+
+		// binding returns the Harmony serving the request and the path prefix
+		// it is mounted under.
+		binding() (h *Harmony, prefix string)
+
+		// bind binds the context to the Harmony h mounted under prefix.
+		bind(h *Harmony, prefix string)
+
+		// setResponse sets the http.ResponseWriter and resets the committed state.
+		setResponse(w http.ResponseWriter)
+
+		// isCommitted reports whether the response has already been written.
+		isCommitted() bool
 	}
 
 	context struct {
-		w     http.ResponseWriter
 		r     *http.Request
-		store Map
+		res   Response
+		ps    Params
+		store map[any]any
 		lock  sync.RWMutex
 		bdr   Binder
+		h     *Harmony
+
+		// prefix is the escaped path prefix h is mounted under.
+		prefix string
 	}
 )
 
 // NewContext returns a new Harmony Context.
 func NewContext(w http.ResponseWriter, r *http.Request, binder Binder) Context {
-	return &context{
-		w:     w,
+	c := &context{
 		r:     r,
-		store: make(Map),
+		store: make(map[any]any),
 		bdr:   binder,
 	}
+	c.setResponse(w)
+	return c
 }
 
 // Request returns the *http.Request object.
@@ -95,9 +225,24 @@ func (c *context) Request() *http.Request {
 	return c.r
 }
 
+// Context returns the context of the request.
+func (c *context) Context() gocontext.Context {
+	return c.r.Context()
+}
+
+// SetContext replaces the context of the request.
+func (c *context) SetContext(ctx gocontext.Context) {
+	c.r = c.r.WithContext(ctx)
+}
+
 // ResponseWriter returns the http.ResponseWriter object.
 func (c *context) ResponseWriter() http.ResponseWriter {
-	return c.w
+	return &c.res
+}
+
+// Response returns the *Response of the request.
+func (c *context) Response() *Response {
+	return &c.res
 }
 
 // Bind binds the request body into dest.
@@ -105,38 +250,85 @@ func (c *context) Bind(dest any) error {
 	return c.bdr.Bind(c, dest)
 }
 
-// JSON writes the response in JSON format.
+// JSON writes the response in JSON format. body is encoded before anything is
+// written, so that an encoding error can still be handled by the error handler.
 func (c *context) JSON(code int, body any) error {
-	c.w.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
-	c.w.WriteHeader(code)
-	return json.NewEncoder(c.w).Encode(body)
+	return c.JSONPretty(code, body, "")
+}
+
+// JSONPretty writes the response in JSON format indented with indent.
+func (c *context) JSONPretty(code int, body any, indent string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(body); err != nil {
+		return err
+	}
+	return c.Blob(code, MIMEApplicationJSONCharsetUTF8, buf.Bytes())
+}
+
+// XML writes the response in XML format, starting with the XML header. body is
+// encoded before anything is written.
+func (c *context) XML(code int, body any) error {
+	b, err := xml.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.res.Header().Set(HeaderContentType, MIMEApplicationXMLCharsetUTF8)
+	c.res.WriteHeader(code)
+	if _, err := io.WriteString(&c.res, xml.Header); err != nil {
+		return err
+	}
+	_, err = c.res.Write(b)
+	return err
+}
+
+// HTML writes the response in HTML format.
+func (c *context) HTML(code int, html string) error {
+	return c.Blob(code, MIMETextHTMLCharsetUTF8, []byte(html))
+}
+
+// Blob writes b as the response with the content type.
+func (c *context) Blob(code int, contentType string, b []byte) error {
+	c.res.Header().Set(HeaderContentType, contentType)
+	c.res.WriteHeader(code)
+	_, err := c.res.Write(b)
+	return err
+}
+
+// Stream copies r to the response with the content type.
+func (c *context) Stream(code int, contentType string, r io.Reader) error {
+	c.res.Header().Set(HeaderContentType, contentType)
+	c.res.WriteHeader(code)
+	_, err := io.Copy(&c.res, r)
+	return err
 }
 
 // PathParams returns the path parameters of the request in map[string]string.
 func (c *context) PathParams() map[string]string {
-	return mux.Vars(c.r)
+	params := make(map[string]string, len(c.ps))
+	for _, p := range c.ps {
+		params[p.Key] = p.Value
+	}
+	return params
 }
 
 // PathParam returns the path parameter of the request by key in string.
 func (c *context) PathParam(key string) string {
-	return mux.Vars(c.r)[key]
+	v, _ := c.ps.Get(key)
+	return v
 }
 
 // PathParamInt returns the path parameter of the request by key in int.
 func (c *context) PathParamInt(key string) (int, error) {
-	return strconv.Atoi(mux.Vars(c.r)[key])
+	return strconv.Atoi(c.PathParam(key))
 }
 
 // SetPathParam sets the path parameter of the request by key and value.
 func (c *context) SetPathParam(key, value string) {
-	vars := make(map[string]string)
-	if len(mux.Vars(c.r)) > 0 {
-		vars = mux.Vars(c.r)
-	}
-	if _, ok := vars[key]; !ok {
-		vars[key] = value
+	if _, ok := c.ps.Get(key); !ok {
+		c.ps = append(c.ps, Param{Key: key, Value: value})
 	}
-	c.r = mux.SetURLVars(c.r, vars)
 }
 
 // QueryString returns the query string of the request in string.
@@ -175,48 +367,250 @@ func (c *context) QueryBool(key string, defaultValue ...bool) bool {
 	return b
 }
 
+// File serves the file, handling Range requests and conditional GET. It
+// returns ErrNotFound if the file does not exist or is a directory.
+func (c *context) File(file string) error {
+	return c.FileFS(os.DirFS(filepath.Dir(file)), filepath.Base(file))
+}
+
+// FileFS serves the file name of fsys like File.
+func (c *context) FileFS(fsys fs.FS, name string) error {
+	return serveFS(c, fsys, name)
+}
+
+// Attachment serves the file to be downloaded and saved as name.
+func (c *context) Attachment(file, name string) error {
+	return c.AttachmentFS(os.DirFS(filepath.Dir(file)), filepath.Base(file), name)
+}
+
+// AttachmentFS serves the file of fsys to be downloaded and saved as name.
+func (c *context) AttachmentFS(fsys fs.FS, file, name string) error {
+	c.res.Header().Set(HeaderContentDisposition, contentDisposition("attachment", name))
+	return serveFS(c, fsys, file)
+}
+
+// Inline serves the file to be displayed by the browser, named name.
+func (c *context) Inline(file, name string) error {
+	return c.InlineFS(os.DirFS(filepath.Dir(file)), filepath.Base(file), name)
+}
+
+// InlineFS serves the file of fsys to be displayed by the browser, named name.
+func (c *context) InlineFS(fsys fs.FS, file, name string) error {
+	c.res.Header().Set(HeaderContentDisposition, contentDisposition("inline", name))
+	return serveFS(c, fsys, file)
+}
+
 // SendStatus writes the response status code.
 func (c *context) SendStatus(code int) error {
-	c.w.WriteHeader(code)
+	c.res.WriteHeader(code)
 	return nil
 }
 
+// NoContent writes the response status code without a body.
+func (c *context) NoContent(code int) error {
+	c.res.WriteHeader(code)
+	return nil
+}
+
+// Redirect redirects the request to url with a 300, 301, 302, 303, 307 or 308
+// status code. It returns ErrInvalidRedirectCode for other codes, and
+// ErrUnsafeRedirect for a url rejected by Config.SafeRedirects.
+func (c *context) Redirect(code int, url string) error {
+	switch code {
+	case http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return ErrInvalidRedirectCode
+	}
+	if c.h != nil && c.h.config.SafeRedirects && !safeRedirect(url, c.r.Host, c.h.config.RedirectHosts) {
+		return ErrUnsafeRedirect
+	}
+	c.res.Header().Set(HeaderLocation, url)
+	c.res.WriteHeader(code)
+	return nil
+}
+
+// RedirectToRoute redirects the request to the route named name with params.
+func (c *context) RedirectToRoute(code int, name string, params ...any) error {
+	url, err := c.URL(name, params...)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(code, url)
+}
+
 // String writes the response in string format.
 func (c *context) String(code int, s string) error {
-	c.w.Header().Set(HeaderContentType, MIMETextPlainCharsetUTF8)
-	c.w.WriteHeader(code)
-	_, err := c.w.Write([]byte(s))
-	return err
+	return c.Blob(code, MIMETextPlainCharsetUTF8, []byte(s))
+}
+
+// URL builds the path of the route named name with params, including the
+// prefix of a mounted app.
+func (c *context) URL(name string, params ...any) (string, error) {
+	if c.h == nil {
+		return "", errors.New("harmony: context is not bound to Harmony")
+	}
+	url, err := c.h.URL(name, params...)
+	if err != nil {
+		return "", err
+	}
+	return c.prefix + url, nil
+}
+
+// PeerCertificate returns the verified client certificate of a TLS request, or nil.
+func (c *context) PeerCertificate() *x509.Certificate {
+	if c.r.TLS == nil || len(c.r.TLS.VerifiedChains) == 0 || len(c.r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return c.r.TLS.VerifiedChains[0][0]
+}
+
+// RealIP returns the IP address of the client. The X-Forwarded-For, Forwarded
+// and X-Real-IP headers are only read from Config.TrustedProxies.
+func (c *context) RealIP() string {
+	if c.h == nil {
+		return realIP(c.r, nil, false)
+	}
+	return realIP(c.r, c.h.config.TrustedProxies, c.h.config.TrustUnixSocket)
+}
+
+// Protocol returns the protocol of the request, "HTTP/1.0", "HTTP/1.1" or "HTTP/2.0".
+func (c *context) Protocol() string {
+	return c.r.Proto
+}
+
+// Cookie returns the cookie of the request named name, or http.ErrNoCookie.
+func (c *context) Cookie(name string) (*http.Cookie, error) {
+	return c.r.Cookie(name)
+}
+
+// Cookies returns the cookies of the request.
+func (c *context) Cookies() []*http.Cookie {
+	return c.r.Cookies()
+}
+
+// SetCookie adds the Set-Cookie header of cookie to the response.
+func (c *context) SetCookie(cookie *http.Cookie) {
+	http.SetCookie(&c.res, cookie)
+}
+
+// ClearCookie asks the client to delete the cookie named name with path "/".
+func (c *context) ClearCookie(name string) {
+	c.SetCookie(&http.Cookie{Name: name, Path: "/", MaxAge: -1, Expires: time.Unix(0, 0)})
+}
+
+// SignedCookie returns the cookie named name set by SetSignedCookie, with its
+// verified value. It returns ErrInvalidCookie if no key of Config.CookieKeys
+// verifies it.
+func (c *context) SignedCookie(name string) (*http.Cookie, error) {
+	cookie, err := c.r.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	if cookie.Value, err = verifyCookie(c.cookieKeys(), name, cookie.Value); err != nil {
+		return nil, err
+	}
+	return cookie, nil
+}
+
+// SetSignedCookie sets the cookie with its value signed with HMAC-SHA256 and
+// the first key of Config.CookieKeys. cookie is not modified.
+func (c *context) SetSignedCookie(cookie *http.Cookie) error {
+	value, err := signCookie(c.cookieKeys(), cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+	signed := *cookie
+	signed.Value = value
+	c.SetCookie(&signed)
+	return nil
+}
+
+// EncryptedCookie returns the cookie named name set by SetEncryptedCookie, with
+// its decrypted value. It returns ErrInvalidCookie if no key of
+// Config.CookieKeys decrypts it.
+func (c *context) EncryptedCookie(name string) (*http.Cookie, error) {
+	cookie, err := c.r.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	if cookie.Value, err = decryptCookie(c.cookieKeys(), name, cookie.Value); err != nil {
+		return nil, err
+	}
+	return cookie, nil
+}
+
+// SetEncryptedCookie sets the cookie with its value encrypted with AES-GCM and
+// the first key of Config.CookieKeys. cookie is not modified.
+func (c *context) SetEncryptedCookie(cookie *http.Cookie) error {
+	value, err := encryptCookie(c.cookieKeys(), cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+	encrypted := *cookie
+	encrypted.Value = value
+	c.SetCookie(&encrypted)
+	return nil
+}
+
+func (c *context) cookieKeys() []cookieKey {
+	if c.h == nil {
+		return nil
+	}
+	return c.h.cookieKeys
 }
 
 // Get returns the value in the context by key.
-func (c *context) Get(key string) any {
+func (c *context) Get(key any) any {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	return c.store[key]
 }
 
 // Set sets the value in the context by key and value.
-func (c *context) Set(key string, value any) {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
+func (c *context) Set(key any, value any) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	if c.store == nil {
-		c.store = make(Map)
+		c.store = make(map[any]any)
 	}
 	c.store[key] = value
 }
 
 func (c *context) reset() {
-	c.w = nil
 	c.r = nil
-	c.store = make(Map)
+	c.res.reset(nil)
+	c.ps = c.ps[:0]
+	c.prefix = ""
+	clear(c.store)
 }
 
-// SetResponseWriter sets the http.ResponseWriter.
+// SetResponseWriter sets the http.ResponseWriter wrapped by Response.
 func (c *context) SetResponseWriter(w http.ResponseWriter) {
-	c.w = w
+	c.res.Writer = w
 }
 
 func (c *context) setRequest(r *http.Request) {
 	c.r = r
 }
+
+func (c *context) pathParams() *Params {
+	return &c.ps
+}
+
+func (c *context) binding() (*Harmony, string) {
+	return c.h, c.prefix
+}
+
+func (c *context) bind(h *Harmony, prefix string) {
+	c.h = h
+	c.prefix = prefix
+}
+
+func (c *context) setResponse(w http.ResponseWriter) {
+	c.res.reset(w)
+}
+
+func (c *context) isCommitted() bool {
+	return c.res.Committed
+}
@@ -1,11 +1,21 @@
 package harmony
 
 import (
+	gocontext "context"
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestContext(t *testing.T) {
@@ -27,6 +37,87 @@ func TestContext_JSON(t *testing.T) {
 	}
 }
 
+func TestContext_JSONPretty(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	if err := newContext(rec, r).JSONPretty(http.StatusOK, testUser, "  "); assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, "{\n  \"id\": 1,\n  \"name\": \"John Doe\"\n}\n", rec.Body.String())
+	}
+
+	// Encoding errors leave the response uncommitted
+	rec = httptest.NewRecorder()
+	ctx := newContext(rec, r)
+	assert.Error(t, ctx.JSON(http.StatusOK, make(chan int)))
+	assert.False(t, ctx.Response().Committed)
+}
+
+func TestContext_XML(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	if err := newContext(rec, r).XML(http.StatusCreated, testUser); assert.NoError(t, err) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, MIMEApplicationXMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, xml.Header+"<user><id>1</id><name>John Doe</name></user>", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	ctx := newContext(rec, r)
+	assert.Error(t, ctx.XML(http.StatusOK, map[string]int{"id": 1}))
+	assert.False(t, ctx.Response().Committed)
+}
+
+func TestContext_HTML(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	if err := newContext(rec, r).HTML(http.StatusOK, "<h1>Harmony</h1>"); assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, "<h1>Harmony</h1>", rec.Body.String())
+	}
+}
+
+func TestContext_Blob(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	ctx := newContext(rec, r)
+	if err := ctx.Blob(http.StatusOK, "image/png", []byte{0x89, 'P', 'N', 'G'}); assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get(HeaderContentType))
+		assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, rec.Body.Bytes())
+		assert.True(t, ctx.Response().Committed)
+		assert.Equal(t, int64(4), ctx.Response().Size)
+	}
+}
+
+func TestContext_Stream(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	if err := newContext(rec, r).Stream(http.StatusOK, MIMEOctetStream, strings.NewReader("stream")); assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MIMEOctetStream, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, "stream", rec.Body.String())
+	}
+}
+
+func TestContext_NoContent(t *testing.T) {
+	r := httptest.NewRequest(http.MethodDelete, "/", nil)
+	rec := httptest.NewRecorder()
+
+	ctx := newContext(rec, r)
+	if err := ctx.NoContent(http.StatusNoContent); assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.True(t, ctx.Response().Committed)
+	}
+}
+
 func TestContext_SetAndGetPathParams(t *testing.T) {
 	ctx := setPathParam()
 	assert.Equal(t, map[string]string{"id": "1"}, ctx.PathParams())
@@ -115,6 +206,47 @@ func TestContext_Bind(t *testing.T) {
 	}
 }
 
+func TestContext_Context(t *testing.T) {
+	type key struct{}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(gocontext.WithValue(r.Context(), key{}, "value"))
+
+	ctx := newContext(httptest.NewRecorder(), r)
+	assert.Same(t, r.Context(), ctx.Context())
+
+	deadline := time.Now().Add(-time.Second)
+	dctx, cancel := gocontext.WithDeadline(ctx.Context(), deadline)
+	defer cancel()
+	ctx.SetContext(dctx)
+	assert.Same(t, dctx, ctx.Context())
+	assert.Same(t, dctx, ctx.Request().Context())
+	<-ctx.Context().Done()
+	assert.ErrorIs(t, ctx.Context().Err(), gocontext.DeadlineExceeded)
+	assert.Equal(t, "value", ctx.Context().Value(key{}))
+}
+
+func TestContext_Context_OutlivesHandler(t *testing.T) {
+	type key struct{}
+	contexts := make(chan gocontext.Context, 2)
+	app := New()
+	app.Get("/", func(ctx Context) error {
+		ctx.SetContext(gocontext.WithValue(ctx.Context(), key{}, ctx.QueryString("id")))
+		contexts <- ctx.Context()
+		return ctx.NoContent(http.StatusAccepted)
+	})
+
+	// The contexts are used once the Context has been reset and reused
+	newRequest(http.MethodGet, "/?id=1", app)
+	newRequest(http.MethodGet, "/?id=2", app)
+	done := make(chan any)
+	go func() {
+		done <- (<-contexts).Value(key{})
+		done <- (<-contexts).Value(key{})
+	}()
+	assert.Equal(t, "1", <-done)
+	assert.Equal(t, "2", <-done)
+}
+
 func newContext(w http.ResponseWriter, r *http.Request) Context {
 	return NewContext(w, r, newBinder())
 }
@@ -125,3 +257,120 @@ func setPathParam() Context {
 	ctx.SetPathParam("id", "1")
 	return ctx
 }
+
+func TestContext_File(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "report.txt")
+	require.NoError(t, os.WriteFile(file, []byte("0123456789"), 0o600))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+
+	app := New()
+	app.Get("/file", func(ctx Context) error {
+		return ctx.File(file)
+	})
+	app.Get("/missing", func(ctx Context) error {
+		return ctx.File(filepath.Join(dir, "missing.txt"))
+	})
+	app.Get("/dir", func(ctx Context) error {
+		return ctx.File(dir)
+	})
+	serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		maps.Copy(r.Header, header)
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve("/file", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0123456789", rec.Body.String())
+	assert.Equal(t, MIMETextPlainCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, modTime.Format(http.TimeFormat), rec.Header().Get("Last-Modified"))
+	etag := rec.Header().Get(HeaderETag)
+	assert.Regexp(t, `^W/"a-[0-9a-f]+"$`, etag)
+
+	rec = serve("/file", http.Header{"Range": {"bytes=2-4"}})
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "bytes 2-4/10", rec.Header().Get("Content-Range"))
+	assert.Equal(t, "234", rec.Body.String())
+
+	rec = serve("/file", http.Header{"Range": {"bytes=0-1,8-9"}})
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Contains(t, rec.Header().Get(HeaderContentType), "multipart/byteranges")
+	assert.Contains(t, rec.Body.String(), "01")
+	assert.Contains(t, rec.Body.String(), "89")
+
+	rec = serve("/file", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = serve("/file", http.Header{"If-Modified-Since": {modTime.Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = serve("/missing", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = serve("/dir", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestContext_Attachment(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "report.csv")
+	require.NoError(t, os.WriteFile(file, []byte("id,name\n"), 0o600))
+	fsys := fstest.MapFS{"logo.svg": {Data: []byte("<svg/>")}}
+
+	app := New()
+	app.Get("/attachment", func(ctx Context) error {
+		return ctx.Attachment(file, "rapport d'été.csv")
+	})
+	app.Get("/inline", func(ctx Context) error {
+		return ctx.Inline(file, "report.csv")
+	})
+	app.Get("/attachment-fs", func(ctx Context) error {
+		return ctx.AttachmentFS(fsys, "logo.svg", `my "logo".svg`)
+	})
+	app.Get("/inline-fs", func(ctx Context) error {
+		return ctx.InlineFS(fsys, "logo.svg", "logo.svg")
+	})
+	app.Get("/file-fs", func(ctx Context) error {
+		return ctx.FileFS(fsys, "logo.svg")
+	})
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/attachment", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,name\n", rec.Body.String())
+	assert.Equal(t, `attachment; filename="rapport d'_t_.csv"; filename*=UTF-8''rapport%20d%27%C3%A9t%C3%A9.csv`,
+		rec.Header().Get(HeaderContentDisposition))
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/inline", nil))
+	assert.Equal(t, `inline; filename="report.csv"`, rec.Header().Get(HeaderContentDisposition))
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/attachment-fs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/svg+xml", rec.Header().Get(HeaderContentType))
+	assert.Equal(t, `attachment; filename="my \"logo\".svg"`, rec.Header().Get(HeaderContentDisposition))
+	// The modification time of the file is unknown, so the ETag is a hash of its content
+	etag := rec.Header().Get(HeaderETag)
+	assert.Equal(t, `"`+fmt.Sprintf("%x", sha256.Sum256([]byte("<svg/>")))+`"`, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/file-fs", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/inline-fs", nil))
+	assert.Equal(t, `inline; filename="logo.svg"`, rec.Header().Get(HeaderContentDisposition))
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/file-fs", nil))
+	assert.Equal(t, "<svg/>", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(HeaderContentDisposition))
+}
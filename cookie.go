@@ -0,0 +1,143 @@
+package harmony
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// minCookieKeyLen is the minimum length of the keys of Config.CookieKeys.
+const minCookieKeyLen = 32
+
+var (
+	// ErrNoCookieKeys is returned by signed and encrypted cookies when
+	// Config.CookieKeys is empty.
+	ErrNoCookieKeys = errors.New("harmony: no cookie keys configured")
+
+	// ErrInvalidCookie is returned for a signed or encrypted cookie that no
+	// key verifies or decrypts, such as a tampered cookie.
+	ErrInvalidCookie = NewHTTPError(http.StatusBadRequest, "invalid cookie")
+)
+
+// cookieKey holds the keys derived from one of Config.CookieKeys, so that the
+// same secret is never used for both signing and encryption.
+type cookieKey struct {
+	sign    []byte
+	encrypt cipher.AEAD
+}
+
+// deriveCookieKeys derives the signing and encryption keys of keys with
+// HMAC-SHA256. It panics if a key is shorter than 32 bytes.
+func deriveCookieKeys(keys [][]byte) []cookieKey {
+	derived := make([]cookieKey, 0, len(keys))
+	for _, key := range keys {
+		if len(key) < minCookieKeyLen {
+			panic("harmony: cookie keys must be at least 32 bytes long")
+		}
+		block, err := aes.NewCipher(deriveKey(key, "harmony cookie encryption"))
+		if err != nil {
+			panic(err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(err)
+		}
+		derived = append(derived, cookieKey{
+			sign:    deriveKey(key, "harmony cookie signature"),
+			encrypt: aead,
+		})
+	}
+	return derived
+}
+
+// deriveKey returns the 32-byte key of key for the purpose.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// signCookie returns value followed by its HMAC-SHA256 signature with the
+// first key. The name is signed too, so that a value cannot be moved to
+// another cookie.
+func signCookie(keys []cookieKey, name, value string) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(value))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(cookieMAC(keys[0].sign, name, encoded)), nil
+}
+
+// verifyCookie returns the value of a cookie signed by signCookie with any key.
+func verifyCookie(keys []cookieKey, name, signed string) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	encoded, sig, ok := strings.Cut(signed, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range keys {
+		if hmac.Equal(mac, cookieMAC(key.sign, name, encoded)) {
+			value, err := base64.RawURLEncoding.DecodeString(encoded)
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+func cookieMAC(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// encryptCookie encrypts value with AES-GCM and the first key, with the name
+// as additional data.
+func encryptCookie(keys []cookieKey, name, value string) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	aead := keys[0].encrypt
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), []byte(name))), nil
+}
+
+// decryptCookie returns the value of a cookie encrypted by encryptCookie with any key.
+func decryptCookie(keys []cookieKey, name, encrypted string) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	b, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range keys {
+		n := key.encrypt.NonceSize()
+		if len(b) < n {
+			return "", ErrInvalidCookie
+		}
+		if value, err := key.encrypt.Open(nil, b[:n], b[n:], []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
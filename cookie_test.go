@@ -0,0 +1,143 @@
+package harmony
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	oldCookieKey = bytes.Repeat([]byte{1}, 32)
+	newCookieKey = bytes.Repeat([]byte{2}, 32)
+)
+
+// cookieApp returns an app setting the "session" cookie with set and reading
+// it back with get.
+func cookieApp(keys [][]byte, set func(Context, *http.Cookie) error, get func(Context, string) (*http.Cookie, error)) *Harmony {
+	app := New(&Config{CookieKeys: keys})
+	app.Post("/", func(ctx Context) error {
+		return set(ctx, &http.Cookie{Name: "session", Value: ctx.QueryString("value"), Path: "/", HttpOnly: true})
+	})
+	app.Get("/", func(ctx Context) error {
+		cookie, err := get(ctx, ctx.QueryString("name"))
+		if err != nil {
+			return err
+		}
+		return ctx.String(http.StatusOK, cookie.Value)
+	})
+	return app
+}
+
+// setCookie returns the cookie set by app for value.
+func setCookie(t *testing.T, app *Harmony, value string) *http.Cookie {
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?value="+value, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	return cookies[0]
+}
+
+// getCookie requests app with the value of cookie sent as the cookie named
+// name, or as cookie itself if name is empty.
+func getCookie(app *Harmony, cookie *http.Cookie, name string) *httptest.ResponseRecorder {
+	if name == "" {
+		name = cookie.Name
+	}
+	r := httptest.NewRequest(http.MethodGet, "/?name="+name, nil)
+	r.AddCookie(&http.Cookie{Name: name, Value: cookie.Value})
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestContext_Cookie(t *testing.T) {
+	app := New()
+	app.Get("/", func(ctx Context) error {
+		cookie, err := ctx.Cookie("theme")
+		if err != nil {
+			return err
+		}
+		assert.Len(t, ctx.Cookies(), 2)
+		_, err = ctx.Cookie("missing")
+		assert.ErrorIs(t, err, http.ErrNoCookie)
+
+		ctx.SetCookie(&http.Cookie{Name: "lang", Value: "th", Path: "/"})
+		ctx.ClearCookie("theme")
+		return ctx.String(http.StatusOK, cookie.Value)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Cookie", "theme=dark; lang=en")
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, r)
+	assert.Equal(t, "dark", rec.Body.String())
+	assert.Equal(t, []string{
+		"lang=th; Path=/",
+		"theme=; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0",
+	}, rec.Header().Values("Set-Cookie"))
+}
+
+func TestContext_SignedCookie(t *testing.T) {
+	set := func(ctx Context, c *http.Cookie) error { return ctx.SetSignedCookie(c) }
+	get := func(ctx Context, name string) (*http.Cookie, error) { return ctx.SignedCookie(name) }
+	app := cookieApp([][]byte{oldCookieKey}, set, get)
+
+	cookie := setCookie(t, app, "user42")
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, strings.HasPrefix(cookie.Value, "dXNlcjQy."))
+	rec := getCookie(app, cookie, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user42", rec.Body.String())
+
+	// Tampered values and values moved to another cookie are rejected
+	encoded, sig, _ := strings.Cut(cookie.Value, ".")
+	tampered := *cookie
+	tampered.Value = encoded + "x." + sig
+	rec = getCookie(app, &tampered, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"message":"invalid cookie"}`, rec.Body.String())
+	assert.Equal(t, http.StatusBadRequest, getCookie(app, cookie, "admin").Code)
+
+	// Old cookies are verified while the keys rotate, new ones use the new key
+	rotated := cookieApp([][]byte{newCookieKey, oldCookieKey}, set, get)
+	assert.Equal(t, "user42", getCookie(rotated, cookie, "").Body.String())
+	newCookie := setCookie(t, rotated, "user42")
+	assert.Equal(t, http.StatusBadRequest, getCookie(app, newCookie, "").Code)
+	assert.Equal(t, http.StatusOK, getCookie(cookieApp([][]byte{newCookieKey}, set, get), newCookie, "").Code)
+}
+
+func TestContext_EncryptedCookie(t *testing.T) {
+	set := func(ctx Context, c *http.Cookie) error { return ctx.SetEncryptedCookie(c) }
+	get := func(ctx Context, name string) (*http.Cookie, error) { return ctx.EncryptedCookie(name) }
+	app := cookieApp([][]byte{oldCookieKey}, set, get)
+
+	cookie := setCookie(t, app, "secret")
+	assert.NotContains(t, cookie.Value, "secret")
+	assert.NotEqual(t, cookie.Value, setCookie(t, app, "secret").Value)
+	assert.Equal(t, "secret", getCookie(app, cookie, "").Body.String())
+
+	tampered := *cookie
+	tampered.Value = "A" + cookie.Value[1:]
+	if tampered.Value == cookie.Value {
+		tampered.Value = "B" + cookie.Value[1:]
+	}
+	assert.Equal(t, http.StatusBadRequest, getCookie(app, &tampered, "").Code)
+	assert.Equal(t, http.StatusBadRequest, getCookie(app, cookie, "admin").Code)
+
+	rotated := cookieApp([][]byte{newCookieKey, oldCookieKey}, set, get)
+	assert.Equal(t, "secret", getCookie(rotated, cookie, "").Body.String())
+	assert.Equal(t, http.StatusBadRequest, getCookie(app, setCookie(t, rotated, "secret"), "").Code)
+}
+
+func TestContext_CookieKeys(t *testing.T) {
+	ctx := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.ErrorIs(t, ctx.SetSignedCookie(&http.Cookie{Name: "session", Value: "1"}), ErrNoCookieKeys)
+	assert.ErrorIs(t, ctx.SetEncryptedCookie(&http.Cookie{Name: "session", Value: "1"}), ErrNoCookieKeys)
+
+	assert.Panics(t, func() { New(&Config{CookieKeys: [][]byte{[]byte("short")}}) })
+}
@@ -24,6 +24,7 @@ export default defineConfig({
         items: [
           { text: 'Binding', link: '/binding' },
           { text: 'Context', link: '/context' },
+          { text: 'Error Handling', link: '/error-handling' },
           {
             text: 'Middlewares',
             collapsed: true,
@@ -34,6 +35,8 @@ export default defineConfig({
             ]
           },
           { text: 'Routing', link: '/routing' },
+          { text: 'Server', link: '/server' },
+          { text: 'Static Files', link: '/static' },
         ]
       },
     ],
@@ -1,14 +1,16 @@
 module github.com/SyntaxCrew/harmony
 
-go 1.21.5
+go 1.22
 
 require (
 	github.com/gorilla/mux v1.8.1
 	github.com/stretchr/testify v1.8.4
+	golang.org/x/net v0.35.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,85 @@
+package harmony
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type (
+	// gorillaRouter is a Router backed by gorilla/mux.
+	gorillaRouter struct {
+		mux      *mux.Router
+		handlers map[*mux.Route]HandlerFunc
+	}
+)
+
+// NewGorillaRouter returns a Router backed by gorilla/mux.
+// Paths may use either the :param and *wildcard syntax or gorilla's {name} syntax.
+func NewGorillaRouter() Router {
+	return &gorillaRouter{
+		mux:      mux.NewRouter(),
+		handlers: make(map[*mux.Route]HandlerFunc),
+	}
+}
+
+// Add registers the handler for the method and path.
+func (gr *gorillaRouter) Add(method, path string, handler HandlerFunc) {
+	r := gr.mux.NewRoute().Path(gorillaPath(path)).Methods(method)
+	gr.handlers[r] = handler
+}
+
+// Find returns the handler registered for the method and path and appends
+// the matched path parameters to params.
+func (gr *gorillaRouter) Find(method, path string, params *Params) HandlerFunc {
+	var match mux.RouteMatch
+	if !gr.mux.Match(&http.Request{Method: method, URL: &url.URL{Path: path}}, &match) {
+		return nil
+	}
+
+	for k, v := range match.Vars {
+		*params = append(*params, Param{Key: k, Value: v})
+	}
+	return gr.handlers[match.Route]
+}
+
+// Methods returns the sorted methods registered for the path.
+func (gr *gorillaRouter) Methods(path string) []string {
+	var methods []string
+	seen := make(map[string]bool)
+	for r := range gr.handlers {
+		ms, _ := r.GetMethods()
+		for _, m := range ms {
+			if seen[m] {
+				continue
+			}
+			var match mux.RouteMatch
+			if gr.mux.Match(&http.Request{Method: m, URL: &url.URL{Path: path}}, &match) {
+				seen[m] = true
+				methods = append(methods, m)
+			}
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// gorillaPath converts :param and *wildcard segments into gorilla's {name} syntax.
+func gorillaPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":"):
+			segments[i] = "{" + s[1:] + "}"
+		case strings.HasPrefix(s, "*") && i == len(segments)-1:
+			name := s[1:]
+			if name == "" {
+				name = "*"
+			}
+			segments[i] = "{" + name + ":.*}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
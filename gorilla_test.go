@@ -0,0 +1,50 @@
+package harmony
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestGorillaRouter_Find(t *testing.T) {
+	r := NewGorillaRouter()
+	r.Add(http.MethodGet, "/users/:id", handlerNamed("harmony"))
+	r.Add(http.MethodGet, "/posts/{id:[0-9]+}", handlerNamed("gorilla"))
+	r.Add(http.MethodGet, "/files/*path", handlerNamed("wildcard"))
+
+	var params Params
+	assert.Equal(t, "harmony", handlerName(r.Find(http.MethodGet, "/users/1", &params)))
+	assert.Equal(t, Params{{Key: "id", Value: "1"}}, params)
+
+	params = params[:0]
+	assert.Equal(t, "gorilla", handlerName(r.Find(http.MethodGet, "/posts/2", &params)))
+	assert.Equal(t, Params{{Key: "id", Value: "2"}}, params)
+
+	params = params[:0]
+	assert.Equal(t, "wildcard", handlerName(r.Find(http.MethodGet, "/files/a/b.txt", &params)))
+	assert.Equal(t, Params{{Key: "path", Value: "a/b.txt"}}, params)
+
+	assert.Nil(t, r.Find(http.MethodGet, "/posts/abc", &params))
+	assert.Nil(t, r.Find(http.MethodPost, "/users/1", &params))
+}
+
+func TestGorillaRouter_Methods(t *testing.T) {
+	r := NewGorillaRouter()
+	r.Add(http.MethodGet, "/users/:id", handlerNamed(""))
+	r.Add(http.MethodPut, "/users/:id", handlerNamed(""))
+	r.Add(http.MethodPost, "/users", handlerNamed(""))
+
+	assert.Equal(t, []string{http.MethodGet, http.MethodPut}, r.Methods("/users/1"))
+	assert.Empty(t, r.Methods("/posts"))
+}
+
+func TestHarmony_GorillaRouter(t *testing.T) {
+	app := New(&Config{Router: NewGorillaRouter()})
+	app.Get("/users/:id", func(ctx Context) error {
+		return ctx.String(http.StatusOK, ctx.PathParam("id"))
+	})
+
+	recCode, recBody := newRequest(http.MethodGet, "/users/1", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "1", recBody)
+}
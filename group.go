@@ -6,8 +6,10 @@ type (
 	// Group is the interface for Harmony's Group.
 	Group struct {
 		harmony     *Harmony
+		parent      *Group
 		prefix      string
 		middlewares []MiddlewareFunc
+		routes      []*Route
 	}
 )
 
@@ -18,58 +20,98 @@ func (g *Group) Use(middlewares ...MiddlewareFunc) {
 
 // Group creates a new Harmony subgroup in the current group
 func (g *Group) Group(path string, middlewares ...MiddlewareFunc) *Group {
-	return g.harmony.Group(g.prefix+path, middlewares...)
+	sg := newGroup(g.prefix+path, g.harmony, g.chain(middlewares)...)
+	sg.parent = g
+	return sg
+}
+
+// Routes returns the routes registered on the group and its subgroups.
+func (g *Group) Routes() []RouteInfo {
+	g.harmony.mu.RLock()
+	defer g.harmony.mu.RUnlock()
+	return g.harmony.routeInfos(g.routes)
 }
 
 // Get adds a GET route to Harmony's Group.
-func (g *Group) Get(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.add(http.MethodGet, path, handlerFunc, middlewares...)
+func (g *Group) Get(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodGet, path, handlerFunc, middlewares...)
 }
 
-// Post adds a POST route to Harmony.
-func (g *Group) Post(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.add(http.MethodPost, path, handlerFunc, middlewares...)
+// Post adds a POST route to Harmony's Group.
+func (g *Group) Post(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodPost, path, handlerFunc, middlewares...)
 }
 
 // Put adds a PUT route to Harmony's Group.
-func (g *Group) Put(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.add(http.MethodPut, path, handlerFunc, middlewares...)
+func (g *Group) Put(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodPut, path, handlerFunc, middlewares...)
 }
 
 // Patch adds a PATCH route to Harmony's Group.
-func (g *Group) Patch(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.add(http.MethodPatch, path, handlerFunc, middlewares...)
+func (g *Group) Patch(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodPatch, path, handlerFunc, middlewares...)
 }
 
 // Delete adds a DELETE route to Harmony's Group.
-func (g *Group) Delete(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.add(http.MethodDelete, path, handlerFunc, middlewares...)
+func (g *Group) Delete(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodDelete, path, handlerFunc, middlewares...)
 }
 
 // Connect adds a CONNECT route to Harmony's Group.
-func (g *Group) Connect(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.add(http.MethodConnect, path, handlerFunc, middlewares...)
+func (g *Group) Connect(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodConnect, path, handlerFunc, middlewares...)
 }
 
 // Options adds an OPTIONS route to Harmony's Group.
-func (g *Group) Options(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.add(http.MethodOptions, path, handlerFunc, middlewares...)
+func (g *Group) Options(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodOptions, path, handlerFunc, middlewares...)
 }
 
 // Head adds a HEAD route to Harmony's Group.
-func (g *Group) Head(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.add(http.MethodHead, path, handlerFunc, middlewares...)
+func (g *Group) Head(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodHead, path, handlerFunc, middlewares...)
+}
+
+// Trace adds a TRACE route to Harmony's Group.
+func (g *Group) Trace(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.add(http.MethodTrace, path, handlerFunc, middlewares...)
+}
+
+// Any adds a route for every HTTP method to Harmony's Group.
+func (g *Group) Any(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	return g.Match(methods, path, handlerFunc, middlewares...)
+}
+
+// Match adds a route for each of the methods to Harmony's Group.
+func (g *Group) Match(methods []string, path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	routes := make([]*Route, 0, len(methods))
+	for _, m := range methods {
+		routes = append(routes, g.add(m, path, handlerFunc, middlewares...))
+	}
+	return routes
+}
+
+func (g *Group) add(method, path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	r := g.harmony.add(method, g.prefix+path, handlerFunc, g.chain(middlewares)...)
+	g.harmony.mu.Lock()
+	defer g.harmony.mu.Unlock()
+	for pg := g; pg != nil; pg = pg.parent {
+		pg.routes = append(pg.routes, r)
+	}
+	return r
 }
 
-func (g *Group) add(method, path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.harmony.add(method, g.prefix+path, handlerFunc, append(g.middlewares, middlewares...)...)
+// chain returns the group middlewares followed by middlewares.
+func (g *Group) chain(middlewares []MiddlewareFunc) []MiddlewareFunc {
+	chain := make([]MiddlewareFunc, 0, len(g.middlewares)+len(middlewares))
+	chain = append(chain, g.middlewares...)
+	return append(chain, middlewares...)
 }
 
 func newGroup(prefix string, harmony *Harmony, middlewares ...MiddlewareFunc) *Group {
 	g := &Group{
-		harmony:     harmony,
-		prefix:      prefix,
-		middlewares: middlewares,
+		harmony: harmony,
+		prefix:  prefix,
 	}
 	g.Use(middlewares...)
 	return g
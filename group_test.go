@@ -1,6 +1,7 @@
 package harmony
 
 import (
+	"github.com/stretchr/testify/assert"
 	"net/http"
 	"testing"
 )
@@ -12,3 +13,33 @@ func TestGroup_Group(t *testing.T) {
 	v1.Get("/users", writeStringOKHandler())
 	testMethod(t, http.MethodGet, "/api/v1/users", app)
 }
+
+func TestGroup_Trace(t *testing.T) {
+	app := New()
+	app.Group("/api").Trace("/users", writeStringOKHandler())
+	recCode, recBody := newRequest(http.MethodTrace, "/api/users", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "OK", recBody)
+}
+
+func TestGroup_Any(t *testing.T) {
+	app := New()
+	routes := app.Group("/api").Any("/webhooks", writeStringOKHandler())
+	assert.Len(t, routes, len(methods))
+	for _, method := range methods {
+		recCode, _ := newRequest(method, "/api/webhooks", app)
+		assert.Equal(t, http.StatusOK, recCode, method)
+	}
+}
+
+func TestGroup_Match(t *testing.T) {
+	app := New()
+	app.Group("/api").Match([]string{http.MethodGet, http.MethodPost}, "/proxy", writeStringOKHandler())
+
+	recCode, _ := newRequest(http.MethodGet, "/api/proxy", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	recCode, _ = newRequest(http.MethodPost, "/api/proxy", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	recCode, _ = newRequest(http.MethodPut, "/api/proxy", app)
+	assert.Equal(t, http.StatusMethodNotAllowed, recCode)
+}
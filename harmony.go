@@ -2,16 +2,14 @@ package harmony
 
 import (
 	gocontext "context"
+	"errors"
 	"fmt"
-	"github.com/gorilla/mux"
-	"log"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
-	"strconv"
+	"sort"
+	"strings"
 	"sync"
-	"syscall"
-	"time"
+	"sync/atomic"
 )
 
 const (
@@ -37,6 +35,24 @@ const (
 	HeaderContentLength = "Content-Length"
 	// HeaderContentEncoding is the header key for Content-Encoding.
 	HeaderContentEncoding = "Content-Encoding"
+	// HeaderAllow is the header key for Allow.
+	HeaderAllow = "Allow"
+	// HeaderLocation is the header key for Location.
+	HeaderLocation = "Location"
+	// HeaderCacheControl is the header key for Cache-Control.
+	HeaderCacheControl = "Cache-Control"
+	// HeaderXForwardedFor is the header key for X-Forwarded-For.
+	HeaderXForwardedFor = "X-Forwarded-For"
+	// HeaderXRealIP is the header key for X-Real-IP.
+	HeaderXRealIP = "X-Real-IP"
+	// HeaderForwarded is the header key for Forwarded.
+	HeaderForwarded = "Forwarded"
+	// HeaderContentDisposition is the header key for Content-Disposition.
+	HeaderContentDisposition = "Content-Disposition"
+	// HeaderETag is the header key for ETag.
+	HeaderETag = "ETag"
+	// HeaderContentRange is the header key for Content-Range.
+	HeaderContentRange = "Content-Range"
 )
 
 const (
@@ -52,16 +68,55 @@ const (
 	MIMETextPlain = "text/plain"
 	// MIMETextPlainCharsetUTF8 is the MIME type for plain text with charset=utf-8.
 	MIMETextPlainCharsetUTF8 = MIMETextPlain + "; " + charsetUTF8
+	// MIMETextHTML is the MIME type for HTML.
+	MIMETextHTML = "text/html"
+	// MIMETextHTMLCharsetUTF8 is the MIME type for HTML with charset=utf-8.
+	MIMETextHTMLCharsetUTF8 = MIMETextHTML + "; " + charsetUTF8
+	// MIMEApplicationXML is the MIME type for XML.
+	MIMEApplicationXML = "application/xml"
+	// MIMEApplicationXMLCharsetUTF8 is the MIME type for XML with charset=utf-8.
+	MIMEApplicationXMLCharsetUTF8 = MIMEApplicationXML + "; " + charsetUTF8
+	// MIMEOctetStream is the MIME type for arbitrary binary data.
+	MIMEOctetStream = "application/octet-stream"
 )
 
 type (
 	// Harmony is the interface for Harmony.
 	Harmony struct {
-		// gmux is the underlying router used by Harmony.
-		gmux *mux.Router
+		// config is the config of Harmony.
+		config Config
+
+		// router is the underlying router used by Harmony.
+		router Router
 
 		// middlewares is the list of middlewares used by Harmony.
-		middlewares []mux.MiddlewareFunc
+		middlewares []MiddlewareFunc
+
+		// routes is the list of routes registered on Harmony.
+		routes []*Route
+
+		// names maps the route names to their Route.
+		names map[string]*Route
+
+		// handler is the compiled chain of global middlewares around dispatch.
+		handler atomic.Pointer[HandlerFunc]
+
+		// notFoundHandler handles the requests matching no route.
+		notFoundHandler HandlerFunc
+
+		// methodNotAllowedHandler handles the requests matching a route path but not its method.
+		methodNotAllowedHandler HandlerFunc
+
+		// freezeOnce makes sure routes and middlewares are compiled only once.
+		freezeOnce sync.Once
+
+		// frozen reports whether routes and middlewares have been compiled.
+		frozen bool
+
+		// mu guards routes, names, middlewares, frozen, the router and the
+		// not found and method not allowed handlers, so that they can be set
+		// while the server is running.
+		mu sync.RWMutex
 
 		// ctxPool is a pool of Context.
 		ctxPool sync.Pool
@@ -69,11 +124,33 @@ type (
 		// srv is the underlying http.Server used by Harmony.
 		srv *http.Server
 
+		// ln is the listener accepting the connections of srv.
+		ln net.Listener
+
+		// srvMu guards srv, ln and closed.
+		srvMu sync.Mutex
+
+		// closed reports whether Shutdown has been called.
+		closed bool
+
+		// startHooks are run in order before the server accepts connections.
+		startHooks []func() error
+
+		// shutdownHooks are run in order once the server has shut down.
+		shutdownHooks []func(ctx gocontext.Context) error
+
+		// cookieKeys are the keys derived from Config.CookieKeys.
+		cookieKeys []cookieKey
+
 		// group is the underlying group of Harmony.
 		group map[string]*Harmony
 
 		// binderPool is a pool of Binder.
 		binderPool sync.Pool
+
+		// HTTPErrorHandler handles the errors returned by handlers and middlewares.
+		// Optional. Default value DefaultHTTPErrorHandler.
+		HTTPErrorHandler HTTPErrorHandler
 	}
 
 	// HandlerFunc is the function signature used by all Harmony handlers.
@@ -82,6 +159,10 @@ type (
 	// MiddlewareFunc is the function signature used by all Harmony middlewares.
 	MiddlewareFunc func(next HandlerFunc) HandlerFunc
 
+	// HTTPErrorHandler is the function signature used to handle the errors
+	// returned by handlers and middlewares.
+	HTTPErrorHandler func(err error, ctx Context)
+
 	// Map is a shortcut for map[string]any.
 	Map map[string]any
 
@@ -92,88 +173,104 @@ type (
 	}
 )
 
-// New returns a new instance of Harmony.
-func New() *Harmony {
-	return &Harmony{
-		gmux:  mux.NewRouter(),
-		group: make(map[string]*Harmony),
+var (
+	// methods is the list of HTTP methods registered by Any.
+	methods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+		http.MethodConnect,
+		http.MethodOptions,
+		http.MethodTrace,
 	}
-}
+)
 
-// ListenAndServe starts the server.
-func (h *Harmony) ListenAndServe(port ...int) error {
-	p := defaultPort
-	if len(port) > 0 {
-		p = port[0]
-	}
+var (
+	// ErrNotFound is returned when no route matches the request.
+	ErrNotFound = NewHTTPError(http.StatusNotFound, http.StatusText(http.StatusNotFound))
 
-	h.gmux.Use(h.middlewares...)
+	// ErrMethodNotAllowed is returned when a route matches the request path but not its method.
+	ErrMethodNotAllowed = NewHTTPError(http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
 
-	h.srv = &http.Server{
-		Addr:         "0.0.0.0:" + strconv.Itoa(p),
-		WriteTimeout: 60 * time.Second,
-		ReadTimeout:  60 * time.Second,
-		IdleTimeout:  60 * time.Second,
-		Handler:      h.gmux,
-	}
+	// ErrServiceUnavailable is returned when a route exceeds its timeout.
+	ErrServiceUnavailable = NewHTTPError(http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
 
-	errCh := make(chan error, 1)
+	// ErrInvalidRedirectCode is returned by Context.Redirect for a status code
+	// other than 300, 301, 302, 303, 307 and 308.
+	ErrInvalidRedirectCode = errors.New("harmony: invalid redirect status code")
 
-	go func() {
-		log.Printf("%s\nharmony: server is listening and serving at :%d", banner, port)
-		if err := h.srv.ListenAndServe(); err != nil {
-			errCh <- err
-		}
-	}()
+	// ErrUnsafeRedirect is returned by Context.Redirect for a target rejected
+	// by Config.SafeRedirects.
+	ErrUnsafeRedirect = NewHTTPError(http.StatusBadRequest, "unsafe redirect")
+)
+
+// New returns a new instance of Harmony.
+func New(config ...*Config) *Harmony {
+	var cfg Config
+	if len(config) > 0 && config[0] != nil {
+		cfg = *config[0]
+	}
+	cfg.setDefaults()
 
-	return <-errCh
+	return &Harmony{
+		config:           cfg,
+		router:           cfg.Router,
+		names:            make(map[string]*Route),
+		cookieKeys:       deriveCookieKeys(cfg.CookieKeys),
+		group:            make(map[string]*Harmony),
+		HTTPErrorHandler: DefaultHTTPErrorHandler,
+		notFoundHandler: func(ctx Context) error {
+			return ErrNotFound
+		},
+		methodNotAllowedHandler: func(ctx Context) error {
+			return ErrMethodNotAllowed
+		},
+	}
 }
 
 // ServeHTTP implements http.Handler.
 func (h *Harmony) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	ctx, ok := h.ctxPool.Get().(Context)
-	if !ok {
-		bdr, ok := h.binderPool.Get().(Binder)
-		if !ok {
-			bdr = newBinder()
-		}
-		ctx = NewContext(w, r, bdr)
-	}
-	defer func() {
-		ctx.reset()
-		h.ctxPool.Put(ctx)
-	}()
+	h.freezeOnce.Do(h.freeze)
 
-	ctx.setRequest(r)
-	ctx.SetResponseWriter(w)
+	ctx := h.acquireContext(w, r)
+	defer h.releaseContext(ctx)
 
-	h.gmux.Use(h.middlewares...)
-	h.gmux.ServeHTTP(ctx.ResponseWriter(), ctx.Request())
+	if err := (*h.handler.Load())(ctx); err != nil {
+		h.HTTPErrorHandler(err, ctx)
+	}
 }
 
-// GracefulShutdown waits for SIGINT and gracefully shutdown the server.
-func (h *Harmony) GracefulShutdown() error {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-
-	<-c
-	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := h.srv.Shutdown(ctx); err != nil {
-		return err
+// Use adds a middleware to Harmony. It is safe to call while the server is
+// running, and applies to the requests starting afterwards.
+func (h *Harmony) Use(middlewares ...MiddlewareFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.middlewares = append(h.middlewares, middlewares...)
+	if h.frozen {
+		h.compileHandler()
 	}
+}
 
-	log.Println("harmony: gracefully shutdown")
-	os.Exit(1)
-	return nil
+// NotFound sets the handler called when no route matches the request.
+// It runs after the global middlewares, and is safe to call while the server
+// is running.
+func (h *Harmony) NotFound(handlerFunc HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.notFoundHandler = handlerFunc
 }
 
-// Use adds a middleware to Harmony.
-func (h *Harmony) Use(middlewares ...MiddlewareFunc) {
-	for _, m := range middlewares {
-		h.middlewares = append(h.middlewares, h.applyMiddleware(m))
-	}
+// MethodNotAllowed sets the handler called when a route matches the request path
+// but not its method. It runs after the global middlewares, with the Allow header
+// already set to the methods registered for the path. It is safe to call while
+// the server is running.
+func (h *Harmony) MethodNotAllowed(handlerFunc HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.methodNotAllowedHandler = handlerFunc
 }
 
 // Group creates a new Harmony group.
@@ -182,48 +279,62 @@ func (h *Harmony) Group(path string, middlewares ...MiddlewareFunc) *Group {
 }
 
 // Get adds a GET route to Harmony.
-func (h *Harmony) Get(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodGet, path, handlerFunc, middlewares...)
+func (h *Harmony) Get(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodGet, path, handlerFunc, middlewares...)
 }
 
 // Post adds a POST route to Harmony.
-func (h *Harmony) Post(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodPost, path, handlerFunc, middlewares...)
+func (h *Harmony) Post(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodPost, path, handlerFunc, middlewares...)
 }
 
 // Put adds a PUT route to Harmony.
-func (h *Harmony) Put(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodPut, path, handlerFunc, middlewares...)
+func (h *Harmony) Put(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodPut, path, handlerFunc, middlewares...)
 }
 
 // Patch adds a PATCH route to Harmony.
-func (h *Harmony) Patch(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodPatch, path, handlerFunc, middlewares...)
+func (h *Harmony) Patch(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodPatch, path, handlerFunc, middlewares...)
 }
 
 // Delete adds a DELETE route to Harmony.
-func (h *Harmony) Delete(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodDelete, path, handlerFunc, middlewares...)
+func (h *Harmony) Delete(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodDelete, path, handlerFunc, middlewares...)
 }
 
 // Connect adds a CONNECT route to Harmony.
-func (h *Harmony) Connect(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodConnect, path, handlerFunc, middlewares...)
+func (h *Harmony) Connect(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodConnect, path, handlerFunc, middlewares...)
 }
 
 // Options adds an OPTIONS route to Harmony.
-func (h *Harmony) Options(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodOptions, path, handlerFunc, middlewares...)
+func (h *Harmony) Options(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodOptions, path, handlerFunc, middlewares...)
 }
 
 // Head adds a HEAD route to Harmony.
-func (h *Harmony) Head(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodHead, path, handlerFunc, middlewares...)
+func (h *Harmony) Head(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodHead, path, handlerFunc, middlewares...)
 }
 
 // Trace adds a TRACE route to Harmony.
-func (h *Harmony) Trace(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.add(http.MethodTrace, path, handlerFunc, middlewares...)
+func (h *Harmony) Trace(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return h.add(http.MethodTrace, path, handlerFunc, middlewares...)
+}
+
+// Any adds a route for every HTTP method to Harmony.
+func (h *Harmony) Any(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	return h.Match(methods, path, handlerFunc, middlewares...)
+}
+
+// Match adds a route for each of the methods to Harmony.
+func (h *Harmony) Match(methods []string, path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	routes := make([]*Route, 0, len(methods))
+	for _, m := range methods {
+		routes = append(routes, h.add(m, path, handlerFunc, middlewares...))
+	}
+	return routes
 }
 
 // NewHTTPError returns a new HTTP error.
@@ -231,59 +342,188 @@ func NewHTTPError(code int, message string) *HTTPError {
 	return &HTTPError{Code: code, Message: message}
 }
 
-func (h *Harmony) add(method, path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) {
-	h.gmux.
-		HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-			ctx, ok := h.ctxPool.Get().(Context)
-			if !ok {
-				bdr, ok := h.binderPool.Get().(Binder)
-				if !ok {
-					bdr = newBinder()
-				}
-				ctx = NewContext(w, r, bdr)
-			}
-			defer func() {
-				ctx.reset()
-				h.ctxPool.Put(ctx)
-			}()
-
-			ctx.SetResponseWriter(w)
-			ctx.setRequest(r)
-
-			for _, middleware := range middlewares {
-				handlerFunc = middleware(handlerFunc)
-			}
-
-			_ = handlerFunc(ctx)
-		}).
-		Methods(method)
-}
-
-func (h *Harmony) applyMiddleware(middleware MiddlewareFunc) mux.MiddlewareFunc {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx, ok := h.ctxPool.Get().(Context)
-			if !ok {
-				bdr, ok := h.binderPool.Get().(Binder)
-				if !ok {
-					bdr = newBinder()
-				}
-				ctx = NewContext(w, r, bdr)
-			}
-			defer func() {
-				ctx.reset()
-				h.ctxPool.Put(ctx)
-			}()
-
-			ctx.SetResponseWriter(w)
-			ctx.setRequest(r)
-
-			_ = middleware(func(ctx Context) error {
-				next.ServeHTTP(ctx.ResponseWriter(), ctx.Request())
-				return nil
-			})(ctx)
-		})
+func (h *Harmony) add(method, path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	r := &Route{
+		Method:      method,
+		Path:        path,
+		harmony:     h,
+		handler:     handlerFunc,
+		middlewares: middlewares,
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.routes = append(h.routes, r)
+	if h.frozen {
+		h.register(r)
+	}
+	return r
+}
+
+// freeze compiles the registered routes and the global middleware chain.
+// Routes and middlewares added afterwards are compiled as they are added.
+func (h *Harmony) freeze() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.routes {
+		h.register(r)
+	}
+	h.compileHandler()
+	h.frozen = true
+}
+
+// compileHandler compiles the global middleware chain around dispatch.
+func (h *Harmony) compileHandler() {
+	handler := applyMiddleware(h.dispatch, h.middlewares...)
+	h.handler.Store(&handler)
+}
+
+// register compiles r and registers it on the router.
+func (h *Harmony) register(r *Route) {
+	r.compile()
+	h.router.Add(r.Method, r.Path, r.serve)
+}
+
+// dispatch runs the route matching the request with the request's Context.
+func (h *Harmony) dispatch(ctx Context) error {
+	r := ctx.Request()
+	if handler := h.find(r.Method, r.URL.Path, ctx.pathParams()); handler != nil {
+		return handler(ctx)
+	}
+
+	if r.Method == http.MethodHead {
+		if handler := h.find(http.MethodGet, r.URL.Path, ctx.pathParams()); handler != nil {
+			return serveHead(ctx, handler)
+		}
+	}
+
+	h.mu.RLock()
+	methods := h.allowedMethods(r.URL.Path)
+	notFound, methodNotAllowed := h.notFoundHandler, h.methodNotAllowedHandler
+	h.mu.RUnlock()
+	if len(methods) > 0 {
+		ctx.ResponseWriter().Header().Set(HeaderAllow, strings.Join(methods, ", "))
+		if r.Method == http.MethodOptions {
+			return ctx.SendStatus(http.StatusNoContent)
+		}
+		return methodNotAllowed(ctx)
+	}
+	return notFound(ctx)
+}
+
+// find returns the handler registered on the router for the method and path.
+func (h *Harmony) find(method, path string, params *Params) HandlerFunc {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.router.Find(method, path, params)
+}
+
+// allowedMethods returns the sorted methods answered for the path, including
+// the HEAD and OPTIONS methods answered automatically.
+func (h *Harmony) allowedMethods(path string) []string {
+	methods := h.router.Methods(path)
+	if len(methods) == 0 {
+		return nil
+	}
+
+	var hasGet, hasHead, hasOptions bool
+	for _, m := range methods {
+		switch m {
+		case http.MethodGet:
+			hasGet = true
+		case http.MethodHead:
+			hasHead = true
+		case http.MethodOptions:
+			hasOptions = true
+		}
+	}
+
+	allowed := append([]string(nil), methods...)
+	if hasGet && !hasHead {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if !hasOptions {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+// serveHead runs the GET handler for a HEAD request, discarding the body while
+// keeping its Content-Length.
+func serveHead(ctx Context, handler HandlerFunc) error {
+	res := ctx.Response()
+	w := res.Writer
+	hw := &headResponseWriter{ResponseWriter: w}
+	res.Writer = hw
+	defer func() { res.Writer = w }()
+
+	err := handler(ctx)
+	hw.finish()
+	return err
+}
+
+func (h *Harmony) acquireContext(w http.ResponseWriter, r *http.Request) Context {
+	ctx, ok := h.ctxPool.Get().(Context)
+	if !ok {
+		bdr, ok := h.binderPool.Get().(Binder)
+		if !ok {
+			bdr = newBinder()
+		}
+		ctx = NewContext(w, r, bdr)
+		ctx.(*context).h = h
+		return ctx
+	}
+	ctx.setResponse(w)
+	ctx.setRequest(r)
+	return ctx
+}
+
+func (h *Harmony) releaseContext(ctx Context) {
+	ctx.reset()
+	h.ctxPool.Put(ctx)
+}
+
+// DefaultHTTPErrorHandler is the default HTTPErrorHandler used by Harmony.
+// It writes *HTTPError as a JSON body with the status code of ErrorStatus.
+// Nothing is written if the response has already been committed.
+func DefaultHTTPErrorHandler(err error, ctx Context) {
+	if ctx.isCommitted() {
+		return
+	}
+
+	code, message := ErrorStatus(err), ""
+	var he *HTTPError
+	if errors.As(err, &he) {
+		message = he.Message
+	}
+	if message == "" {
+		message = http.StatusText(code)
+	}
+
+	if ctx.Request().Method == http.MethodHead {
+		_ = ctx.SendStatus(code)
+		return
+	}
+	_ = ctx.JSON(code, Map{"message": message})
+}
+
+// ErrorStatus returns the status code DefaultHTTPErrorHandler writes for err:
+// the code of *HTTPError, or 500 Internal Server Error for any other error.
+// Middlewares and custom error handlers use it to agree on the status of err.
+func ErrorStatus(err error) int {
+	var he *HTTPError
+	if errors.As(err, &he) && he.Code != 0 {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// applyMiddleware wraps handler with middlewares, the first middleware being the outermost.
+func applyMiddleware(handler HandlerFunc, middlewares ...MiddlewareFunc) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
 	}
+	return handler
 }
 
 func (e *HTTPError) Error() string {
@@ -2,17 +2,21 @@ package harmony
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 type (
 	user struct {
-		ID   int    `json:"id"`
-		Name string `json:"name"`
+		ID   int    `json:"id" xml:"id"`
+		Name string `json:"name" xml:"name"`
 	}
 )
 
@@ -79,6 +83,31 @@ func TestHarmony_Head(t *testing.T) {
 	testMethod(t, http.MethodHead, "/", app)
 }
 
+func TestHarmony_Any(t *testing.T) {
+	app := New()
+	routes := app.Any("/webhooks", writeStringOKHandler())
+	assert.Len(t, routes, len(methods))
+	for _, method := range methods {
+		recCode, _ := newRequest(method, "/webhooks", app)
+		assert.Equal(t, http.StatusOK, recCode, method)
+	}
+}
+
+func TestHarmony_Match(t *testing.T) {
+	app := New()
+	routes := app.Match([]string{http.MethodGet, http.MethodPost}, "/proxy", writeStringOKHandler())
+	if assert.Len(t, routes, 2) {
+		assert.Equal(t, http.MethodGet, routes[0].Method)
+		assert.Equal(t, http.MethodPost, routes[1].Method)
+	}
+
+	recCode, recBody := newRequest(http.MethodPost, "/proxy", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "OK", recBody)
+	recCode, _ = newRequest(http.MethodDelete, "/proxy", app)
+	assert.Equal(t, http.StatusMethodNotAllowed, recCode)
+}
+
 func TestHarmony_Middleware(t *testing.T) {
 	app := New()
 	buf := bytes.NewBuffer([]byte{})
@@ -126,6 +155,279 @@ func TestHarmony_MultiGroups(t *testing.T) {
 	testMethod(t, http.MethodGet, "/v2/users", app)
 }
 
+func TestHarmony_SharedContext(t *testing.T) {
+	app := New()
+	var contexts []Context
+	record := func(key string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx Context) error {
+				contexts = append(contexts, ctx)
+				ctx.Set(key, true)
+				return next(ctx)
+			}
+		}
+	}
+	app.Use(record("global"))
+	api := app.Group("/api", record("group"))
+	api.Get("/users/:id", func(ctx Context) error {
+		contexts = append(contexts, ctx)
+		assert.Equal(t, true, ctx.Get("global"))
+		assert.Equal(t, true, ctx.Get("group"))
+		assert.Equal(t, true, ctx.Get("route"))
+		return ctx.String(http.StatusOK, ctx.PathParam("id"))
+	}, record("route"))
+
+	recCode, recBody := newRequest(http.MethodGet, "/api/users/1", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "1", recBody)
+	if assert.Len(t, contexts, 4) {
+		for _, ctx := range contexts[1:] {
+			assert.Same(t, contexts[0], ctx)
+		}
+	}
+}
+
+func TestHarmony_MiddlewareOrder(t *testing.T) {
+	app := New()
+	buf := bytes.NewBuffer([]byte{})
+	write := func(s string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx Context) error {
+				buf.WriteString(s)
+				return next(ctx)
+			}
+		}
+	}
+	app.Use(write("1"))
+	api := app.Group("/api", write("2"))
+	v1 := api.Group("/v1", write("3"))
+	v1.Get("/users", writeStringOKHandler(), write("4"), write("5"))
+
+	recCode, recBody := newRequest(http.MethodGet, "/api/v1/users", app)
+	assert.Equal(t, "12345", buf.String())
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "OK", recBody)
+}
+
+func TestHarmony_CompileOnce(t *testing.T) {
+	app := New()
+	var compiled int
+	count := func(next HandlerFunc) HandlerFunc {
+		compiled++
+		return next
+	}
+	app.Use(count)
+	app.Group("/api", count).Get("/users", writeStringOKHandler(), count)
+
+	for i := 0; i < 10; i++ {
+		recCode, recBody := newRequest(http.MethodGet, "/api/users", app)
+		assert.Equal(t, http.StatusOK, recCode)
+		assert.Equal(t, "OK", recBody)
+	}
+	assert.Equal(t, 3, compiled)
+}
+
+func TestHarmony_AddAfterFreeze(t *testing.T) {
+	app := New()
+	app.Get("/", writeStringOKHandler())
+	testMethod(t, http.MethodGet, "/", app)
+
+	buf := bytes.NewBuffer([]byte{})
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			buf.WriteString("1")
+			return next(ctx)
+		}
+	})
+	testMethod(t, http.MethodGet, "/users", app)
+	assert.Equal(t, "1", buf.String())
+}
+
+func TestHarmony_NotFound(t *testing.T) {
+	app := New()
+	app.Get("/users", writeStringOKHandler())
+
+	recCode, recBody := newRequest(http.MethodGet, "/posts", app)
+	assert.Equal(t, http.StatusNotFound, recCode)
+	assert.JSONEq(t, `{"message":"Not Found"}`, recBody)
+
+	buf := bytes.NewBuffer([]byte{})
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			buf.WriteString("1")
+			return next(ctx)
+		}
+	})
+	app.NotFound(func(ctx Context) error {
+		return ctx.String(http.StatusNotFound, "nothing here")
+	})
+
+	recCode, recBody = newRequest(http.MethodGet, "/posts", app)
+	assert.Equal(t, http.StatusNotFound, recCode)
+	assert.Equal(t, "nothing here", recBody)
+	assert.Equal(t, "1", buf.String())
+}
+
+func TestHarmony_MethodNotAllowed(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", writeStringOKHandler())
+	app.Put("/users/:id", writeStringOKHandler())
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, PUT", rec.Header().Get(HeaderAllow))
+	assert.JSONEq(t, `{"message":"Method Not Allowed"}`, rec.Body.String())
+
+	app.MethodNotAllowed(func(ctx Context) error {
+		return ctx.String(http.StatusMethodNotAllowed, ctx.ResponseWriter().Header().Get(HeaderAllow))
+	})
+	recCode, recBody := newRequest(http.MethodDelete, "/users/1", app)
+	assert.Equal(t, http.StatusMethodNotAllowed, recCode)
+	assert.Equal(t, "GET, HEAD, OPTIONS, PUT", recBody)
+}
+
+func TestHarmony_AutoHead(t *testing.T) {
+	app := New()
+	app.Get("/users", func(ctx Context) error {
+		ctx.ResponseWriter().Header().Set("X-Total", "1")
+		return ctx.String(http.StatusOK, "John Doe")
+	})
+	app.Get("/posts", writeStringOKHandler())
+	app.Head("/posts", func(ctx Context) error {
+		return ctx.SendStatus(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodHead, "/users", nil)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "8", rec.Header().Get(HeaderContentLength))
+	assert.Equal(t, "1", rec.Header().Get("X-Total"))
+	assert.Empty(t, rec.Body.String())
+
+	recCode, recBody := newRequest(http.MethodHead, "/posts", app)
+	assert.Equal(t, http.StatusNoContent, recCode)
+	assert.Empty(t, recBody)
+}
+
+func TestHarmony_AutoOptions(t *testing.T) {
+	app := New()
+	app.Get("/users", writeStringOKHandler())
+	app.Post("/users", writeStringOKHandler())
+	app.Options("/posts", writeStringOKHandler())
+	app.Get("/posts", writeStringOKHandler())
+
+	req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", rec.Header().Get(HeaderAllow))
+
+	testMethod(t, http.MethodOptions, "/posts", app)
+
+	recCode, _ := newRequest(http.MethodOptions, "/comments", app)
+	assert.Equal(t, http.StatusNotFound, recCode)
+}
+
+func TestHarmony_AddWhileServing(t *testing.T) {
+	app := New()
+	app.Get("/", writeStringOKHandler())
+	newRequest(http.MethodGet, "/", app)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			path := "/routes/" + strconv.Itoa(i)
+			app.Get(path, writeStringOKHandler()).Name(path).Timeout(time.Second)
+			app.Use(func(next HandlerFunc) HandlerFunc { return next })
+		}
+	}()
+	handlersDone := make(chan struct{})
+	go func() {
+		defer close(handlersDone)
+		for i := 0; i < 50; i++ {
+			app.NotFound(func(ctx Context) error { return ctx.SendStatus(http.StatusGone) })
+			app.MethodNotAllowed(func(ctx Context) error { return ctx.SendStatus(http.StatusConflict) })
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		code, _ := newRequest(http.MethodGet, "/", app)
+		assert.Equal(t, http.StatusOK, code)
+		newRequest(http.MethodHead, "/routes/"+strconv.Itoa(i), app)
+		newRequest(http.MethodGet, "/missing", app)
+		newRequest(http.MethodPost, "/", app)
+		_, _ = app.URL("/routes/" + strconv.Itoa(i))
+		_ = app.Routes()
+	}
+	<-done
+	<-handlersDone
+
+	code, body := newRequest(http.MethodGet, "/routes/49", app)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "OK", body)
+	assert.Len(t, app.Routes(), 51)
+	code, _ = newRequest(http.MethodGet, "/missing", app)
+	assert.Equal(t, http.StatusGone, code)
+	code, _ = newRequest(http.MethodPost, "/", app)
+	assert.Equal(t, http.StatusConflict, code)
+}
+
+func TestHarmony_HTTPErrorHandler(t *testing.T) {
+	app := New()
+	app.Get("/not-found", func(ctx Context) error {
+		return NewHTTPError(http.StatusNotFound, "user not found")
+	})
+	app.Get("/internal", func(ctx Context) error {
+		return errors.New("database is down")
+	})
+	app.Get("/committed", func(ctx Context) error {
+		_ = ctx.String(http.StatusAccepted, "OK")
+		return errors.New("failed after write")
+	})
+
+	recCode, recBody := newRequest(http.MethodGet, "/not-found", app)
+	assert.Equal(t, http.StatusNotFound, recCode)
+	assert.JSONEq(t, `{"message":"user not found"}`, recBody)
+
+	recCode, recBody = newRequest(http.MethodGet, "/internal", app)
+	assert.Equal(t, http.StatusInternalServerError, recCode)
+	assert.JSONEq(t, `{"message":"Internal Server Error"}`, recBody)
+
+	recCode, recBody = newRequest(http.MethodGet, "/committed", app)
+	assert.Equal(t, http.StatusAccepted, recCode)
+	assert.Equal(t, "OK", recBody)
+}
+
+func TestErrorStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, ErrorStatus(ErrNotFound))
+	assert.Equal(t, http.StatusBadRequest, ErrorStatus(fmt.Errorf("wrapped: %w", ErrUnsafeRedirect)))
+	assert.Equal(t, http.StatusInternalServerError, ErrorStatus(&HTTPError{Message: "no code"}))
+	assert.Equal(t, http.StatusInternalServerError, ErrorStatus(errors.New("database is down")))
+}
+
+func TestHarmony_CustomHTTPErrorHandler(t *testing.T) {
+	app := New()
+	var handled error
+	app.HTTPErrorHandler = func(err error, ctx Context) {
+		handled = err
+		_ = ctx.String(http.StatusTeapot, err.Error())
+	}
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		}
+	})
+	app.Get("/", writeStringOKHandler())
+
+	recCode, recBody := newRequest(http.MethodGet, "/", app)
+	assert.Equal(t, http.StatusTeapot, recCode)
+	assert.Equal(t, "harmony: code=401, message=unauthorized", recBody)
+	assert.Equal(t, NewHTTPError(http.StatusUnauthorized, "unauthorized"), handled)
+}
+
 func newRequest(method, path string, h *Harmony, body ...string) (int, string) {
 	var b string
 	if len(body) > 0 {
@@ -149,3 +451,37 @@ func writeStringOKHandler() HandlerFunc {
 		return ctx.String(http.StatusOK, "OK")
 	}
 }
+
+func BenchmarkHarmony_ServeHTTP(b *testing.B) {
+	app := New()
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			return next(ctx)
+		}
+	})
+	app.Get("/users", func(ctx Context) error {
+		return ctx.SendStatus(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	w := &discardResponseWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		app.ServeHTTP(w, req)
+	}
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
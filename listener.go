@@ -0,0 +1,110 @@
+package harmony
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd socket activation.
+const listenFdsStart = 3
+
+// ListenUnix starts the server on the Unix socket at path with the file mode.
+// The mode is applied after the socket is created; on Unix, the socket is
+// created under a umask allowing no more than mode in the meantime. A stale
+// socket left by a previous process is removed first. When the process
+// is started by systemd socket activation or Restart, the server accepts on the
+// passed socket instead.
+func (h *Harmony) ListenUnix(path string, mode os.FileMode) error {
+	if l, err := passedListener(); l != nil || err != nil {
+		if err != nil {
+			return err
+		}
+		return h.Serve(l)
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return err
+	}
+
+	l, err := listenUnix(path, mode)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = l.Close()
+		return err
+	}
+	return h.Serve(l)
+}
+
+// listen returns the listener passed by systemd socket activation or Restart,
+// if any, or listens on Config.Addr, or on its host and port if given.
+func (h *Harmony) listen(port ...int) (net.Listener, error) {
+	if l, err := passedListener(); l != nil || err != nil {
+		return l, err
+	}
+
+	addr := h.config.Addr
+	if len(port) > 0 {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		addr = net.JoinHostPort(host, strconv.Itoa(port[0]))
+	}
+	return net.Listen("tcp", addr)
+}
+
+// passedListener returns the listener passed by systemd socket activation or
+// by the parent process of Restart, or nil if there is none.
+func passedListener() (net.Listener, error) {
+	if l, err := activationListener(); l != nil || err != nil {
+		return l, err
+	}
+	return inheritedListener()
+}
+
+// activationListener returns the first listener passed by systemd socket
+// activation, or nil when the process was not socket activated. The LISTEN_*
+// variables are unset so that child processes do not inherit them.
+func activationListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, nil
+	}
+
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(listenFdsStart, "LISTEN_FD_"+strconv.Itoa(listenFdsStart))
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// removeStaleSocket removes the socket at path unless a server is still accepting on it.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&fs.ModeSocket == 0 {
+		return errors.New("harmony: " + path + " exists and is not a socket")
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+		return errors.New("harmony: socket " + path + " is in use")
+	}
+	return os.Remove(path)
+}
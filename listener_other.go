@@ -0,0 +1,14 @@
+//go:build !unix
+
+package harmony
+
+import (
+	"net"
+	"os"
+)
+
+// listenUnix listens on the Unix socket at path. There is no umask on this
+// platform, so mode is only applied once the socket is created.
+func listenUnix(path string, _ os.FileMode) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
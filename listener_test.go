@@ -0,0 +1,137 @@
+package harmony
+
+import (
+	gocontext "context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHarmony_Serve(t *testing.T) {
+	app := New(&Config{HideBanner: true})
+	app.Get("/", writeStringOKHandler())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Serve(l)
+	}()
+
+	code, body := getBody(t, http.DefaultClient, "http://"+l.Addr().String()+"/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "OK", body)
+
+	require.NoError(t, l.Close())
+	assert.Error(t, <-done)
+}
+
+func TestHarmony_ListenUnix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "harmony.sock")
+
+	// Leave a stale socket behind, as a crashed process would
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	app := New(&Config{HideBanner: true})
+	app.Get("/", writeStringOKHandler())
+	go func() {
+		_ = app.ListenUnix(path, 0o660)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx gocontext.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("http://unix/")
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	code, body := getBody(t, client, "http://unix/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "OK", body)
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o660), fi.Mode().Perm())
+
+	t.Run("in use", func(t *testing.T) {
+		assert.ErrorContains(t, New().ListenUnix(path, 0o660), "in use")
+	})
+
+	t.Run("not a socket", func(t *testing.T) {
+		file := filepath.Join(dir, "file")
+		require.NoError(t, os.WriteFile(file, nil, 0o600))
+		assert.ErrorContains(t, New().ListenUnix(file, 0o660), "not a socket")
+	})
+}
+
+func TestHarmony_ListenAndServe_SocketActivation(t *testing.T) {
+	if os.Getenv("HARMONY_TEST_SOCKET_ACTIVATION") == "1" {
+		// systemd sets LISTEN_PID once it knows the pid of the service
+		require.NoError(t, os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid())))
+		app := New(&Config{Addr: "invalid", HideBanner: true})
+		app.Get("/", writeStringOKHandler())
+		_ = app.ListenAndServe()
+		return
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f, err := l.(*net.TCPListener).File()
+	require.NoError(t, err)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHarmony_ListenAndServe_SocketActivation$")
+	cmd.Env = append(os.Environ(), "HARMONY_TEST_SOCKET_ACTIVATION=1", "LISTEN_FDS=1")
+	cmd.ExtraFiles = []*os.File{f}
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	// The child process accepts on its own copy of the socket
+	addr := l.Addr().String()
+	require.NoError(t, f.Close())
+	require.NoError(t, l.Close())
+
+	code, body := getBody(t, http.DefaultClient, "http://"+addr+"/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "OK", body)
+}
+
+func TestActivationListener(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	l, err := activationListener()
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+}
+
+func getBody(t *testing.T, client *http.Client, url string) (int, string) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
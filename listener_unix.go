@@ -0,0 +1,20 @@
+//go:build unix
+
+package harmony
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenUnix listens on the Unix socket at path under a umask allowing no more
+// than mode, so that the socket is never more accessible than mode before it
+// is changed to mode. The umask is process-wide, so it is only set while the
+// socket is created.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	umask := syscall.Umask(int(^mode.Perm() & 0o777))
+	l, err := net.Listen("unix", path)
+	syscall.Umask(umask)
+	return l, err
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package harmony
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestListenUnix_Umask(t *testing.T) {
+	// A permissive umask must not leave the socket open to everyone before chmod
+	umask := syscall.Umask(0)
+	defer syscall.Umask(umask)
+
+	path := filepath.Join(t.TempDir(), "harmony.sock")
+	l, err := listenUnix(path, 0o660)
+	require.NoError(t, err)
+	defer l.Close()
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o660), fi.Mode().Perm())
+
+	// The umask of the process is restored
+	assert.Equal(t, 0, syscall.Umask(0))
+}
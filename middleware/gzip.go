@@ -1,13 +1,12 @@
 package middleware
 
 import (
-	"bufio"
 	"bytes"
 	"compress/gzip"
 	"github.com/SyntaxCrew/harmony"
 	"io"
-	"net"
 	"net/http"
+	"strings"
 	"sync"
 )
 
@@ -31,15 +30,17 @@ type (
 		MinLength int
 	}
 
+	// gzipResponseWriter delays the header and buffers the body until
+	// MinLength bytes are written, then compresses the rest of the body.
 	gzipResponseWriter struct {
-		io.Writer
 		http.ResponseWriter
-		code                int
-		minLength           int
-		isWroteHeader       bool
-		isWroteBody         bool
-		isMinLengthExceeded bool
-		buffer              *bytes.Buffer
+		gw          *gzip.Writer
+		buffer      *bytes.Buffer
+		minLength   int
+		code        int
+		wroteHeader bool
+		started     bool
+		compress    bool
 	}
 )
 
@@ -52,6 +53,9 @@ func Gzip(gzipCfg ...*GzipConfig) harmony.MiddlewareFunc {
 	if len(gzipCfg) > 0 {
 		cfg = gzipCfg[0]
 	}
+	if cfg.Skipper == nil {
+		cfg.Skipper = defaultSkipper
+	}
 
 	gzipPool := sync.Pool{
 		New: func() any {
@@ -72,113 +76,135 @@ func Gzip(gzipCfg ...*GzipConfig) harmony.MiddlewareFunc {
 			if cfg.Skipper(ctx) {
 				return next(ctx)
 			}
-			rw := ctx.ResponseWriter()
-			rw.Header().Add(harmony.HeaderVary, harmony.HeaderAcceptEncoding)
+			res := ctx.Response()
+			res.Header().Add(harmony.HeaderVary, harmony.HeaderAcceptEncoding)
+			if !strings.Contains(ctx.Request().Header.Get(harmony.HeaderAcceptEncoding), gzipScheme) {
+				return next(ctx)
+			}
 
 			gp := gzipPool.Get()
 			gw, ok := gp.(*gzip.Writer)
 			if !ok {
 				return harmony.NewHTTPError(http.StatusInternalServerError, gp.(error).Error())
 			}
-			defer func() {
-				gw.Reset(rw)
-				gzipPool.Put(gw)
-				_ = gw.Close()
-			}()
+			buf := bufferPool.Get().(*bytes.Buffer)
 
-			bp := bufferPool.Get()
-			buf, ok := bp.(*bytes.Buffer)
-			if !ok {
-				return harmony.NewHTTPError(http.StatusInternalServerError, bp.(error).Error())
-			}
+			rw := res.Writer
+			grw := &gzipResponseWriter{ResponseWriter: rw, gw: gw, buffer: buf, minLength: cfg.MinLength}
+			res.Writer = grw
 			defer func() {
+				grw.finish()
+				res.Writer = rw
+				gw.Reset(io.Discard)
+				gzipPool.Put(gw)
 				buf.Reset()
 				bufferPool.Put(buf)
 			}()
-
-			grw := newGzipResponseWriter(rw, gw, cfg.MinLength, buf)
-			defer func() {
-				if grw.isWroteHeader {
-					rw.WriteHeader(grw.code)
-				}
-
-				if !grw.isWroteBody {
-					if rw.Header().Get(harmony.HeaderContentEncoding) == gzipScheme {
-						rw.Header().Del(harmony.HeaderContentEncoding)
-					}
-
-				} else if !grw.isMinLengthExceeded {
-					_, _ = grw.buffer.WriteTo(rw)
-				}
-			}()
 			return next(ctx)
 		}
 	}
 }
 
-// WriteHeader implements http.ResponseWriter.
+// WriteHeader implements http.ResponseWriter. The header is delayed until it
+// is known whether the body is compressed.
 func (grw *gzipResponseWriter) WriteHeader(code int) {
-	if grw.isWroteHeader {
+	if grw.wroteHeader {
 		return
 	}
-	grw.Header().Del(harmony.HeaderContentLength)
-	grw.isWroteHeader = true
+	grw.wroteHeader = true
 	grw.code = code
-	grw.ResponseWriter.WriteHeader(code)
 }
 
 // Write implements io.Writer.
 func (grw *gzipResponseWriter) Write(b []byte) (int, error) {
-	if grw.isWroteBody {
-		return 0, nil
+	if !grw.wroteHeader {
+		grw.WriteHeader(http.StatusOK)
 	}
-
-	if grw.Header().Get(harmony.HeaderContentType) == "" {
-		grw.Header().Set(harmony.HeaderContentType, http.DetectContentType(b))
+	if grw.started {
+		if grw.compress {
+			return grw.gw.Write(b)
+		}
+		return grw.ResponseWriter.Write(b)
 	}
-	grw.isWroteBody = true
 
-	if !grw.isMinLengthExceeded {
-		n, err := grw.buffer.Write(b)
-		if err != nil {
+	n, _ := grw.buffer.Write(b)
+	if grw.buffer.Len() >= grw.minLength {
+		if err := grw.start(true); err != nil {
 			return 0, err
 		}
-
-		if grw.buffer.Len() >= grw.minLength {
-			grw.isMinLengthExceeded = true
-			grw.Header().Add(harmony.HeaderContentEncoding, gzipScheme)
-			grw.WriteHeader(grw.code)
-			return grw.Writer.Write(grw.buffer.Bytes())
-		}
-		return n, nil
 	}
-	return grw.Writer.Write(b)
+	return n, nil
 }
 
-// Flush implements http.Flusher.
+// Flush implements http.Flusher. The body is compressed from now on because
+// the length of the rest of the body is unknown.
 func (grw *gzipResponseWriter) Flush() {
-	if !grw.isMinLengthExceeded {
-		// Enforce compression because we will not know how much more data will come
-		grw.isMinLengthExceeded = true
-		grw.Header().Set(harmony.HeaderContentEncoding, gzipScheme) // Issue #806
-		if grw.isWroteHeader {
-			grw.ResponseWriter.WriteHeader(grw.code)
-		}
+	if !grw.wroteHeader {
+		grw.WriteHeader(http.StatusOK)
+	}
+	if !grw.started {
+		_ = grw.start(true)
+	}
+	if grw.compress {
+		_ = grw.gw.Flush()
+	}
+	_ = http.NewResponseController(grw.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (grw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return grw.ResponseWriter
+}
 
-		_, _ = grw.Writer.Write(grw.buffer.Bytes())
+// start writes the delayed header and the buffered body, compressed if
+// compress is true and the handler has not encoded the body itself.
+func (grw *gzipResponseWriter) start(compress bool) error {
+	grw.started = true
+	header := grw.Header()
+	if header.Get(harmony.HeaderContentType) == "" && grw.buffer.Len() > 0 {
+		header.Set(harmony.HeaderContentType, http.DetectContentType(grw.buffer.Bytes()))
 	}
+	// A partial response is not compressed, as Content-Range counts the bytes
+	// of the identity encoding.
+	if compress && header.Get(harmony.HeaderContentEncoding) == "" && bodyAllowed(grw.code) &&
+		grw.code != http.StatusPartialContent && header.Get(harmony.HeaderContentRange) == "" {
+		grw.compress = true
+		header.Set(harmony.HeaderContentEncoding, gzipScheme)
+		header.Del(harmony.HeaderContentLength)
+		// The compressed body differs byte for byte, so a strong ETag is weakened.
+		if etag := header.Get(harmony.HeaderETag); strings.HasPrefix(etag, `"`) {
+			header.Set(harmony.HeaderETag, "W/"+etag)
+		}
+		grw.gw.Reset(grw.ResponseWriter)
+	}
+	grw.ResponseWriter.WriteHeader(grw.code)
 
-	_ = grw.Writer.(*gzip.Writer).Flush()
-	if flusher, ok := grw.ResponseWriter.(http.Flusher); ok {
-		flusher.Flush()
+	if grw.buffer.Len() == 0 {
+		return nil
 	}
+	if grw.compress {
+		_, err := grw.gw.Write(grw.buffer.Bytes())
+		return err
+	}
+	_, err := grw.buffer.WriteTo(grw.ResponseWriter)
+	return err
 }
 
-// Hijack implements http.Hijacker.
-func (grw *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return grw.ResponseWriter.(http.Hijacker).Hijack()
+// finish writes a body shorter than MinLength uncompressed, or completes the
+// gzip stream.
+func (grw *gzipResponseWriter) finish() {
+	if !grw.wroteHeader {
+		return
+	}
+	if !grw.started {
+		_ = grw.start(false)
+	}
+	if grw.compress {
+		_ = grw.gw.Close()
+	}
 }
 
-func newGzipResponseWriter(rw http.ResponseWriter, w io.Writer, minLength int, buffer *bytes.Buffer) *gzipResponseWriter {
-	return &gzipResponseWriter{Writer: w, ResponseWriter: rw, minLength: minLength, buffer: buffer}
+// bodyAllowed reports whether a response with the status code may have a body.
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
 }
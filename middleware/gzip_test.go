@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"github.com/SyntaxCrew/harmony"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGzip(t *testing.T) {
+	body := strings.Repeat("harmony ", 32)
+	app := harmony.New()
+	app.Use(Gzip(&GzipConfig{Level: gzip.BestSpeed, MinLength: 64}))
+	app.Get("/long", func(ctx harmony.Context) error {
+		return ctx.String(http.StatusOK, body)
+	})
+	app.Get("/short", func(ctx harmony.Context) error {
+		return ctx.String(http.StatusCreated, "short")
+	})
+	app.Get("/empty", func(ctx harmony.Context) error {
+		return ctx.SendStatus(http.StatusNoContent)
+	})
+	app.Get("/stream", func(ctx harmony.Context) error {
+		res := ctx.Response()
+		_, _ = res.Write([]byte("chunk"))
+		res.Flush()
+		_, _ = res.Write([]byte("chunk"))
+		return nil
+	})
+	app.Get("/error", func(ctx harmony.Context) error {
+		return harmony.ErrNotFound
+	})
+	app.Get("/file", func(ctx harmony.Context) error {
+		return ctx.FileFS(fstest.MapFS{"app.txt": {Data: []byte(body)}}, "app.txt")
+	})
+
+	serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(harmony.HeaderAcceptEncoding, acceptEncoding)
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+	gunzip := func(rec *httptest.ResponseRecorder) string {
+		gr, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		b, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	rec := serve("/long", "gzip, deflate")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get(harmony.HeaderContentEncoding))
+	assert.Equal(t, harmony.HeaderAcceptEncoding, rec.Header().Get(harmony.HeaderVary))
+	assert.Equal(t, harmony.MIMETextPlainCharsetUTF8, rec.Header().Get(harmony.HeaderContentType))
+	assert.Equal(t, body, gunzip(rec))
+
+	// Bodies shorter than MinLength are not compressed
+	rec = serve("/short", "gzip")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(harmony.HeaderContentEncoding))
+	assert.Equal(t, "short", rec.Body.String())
+
+	rec = serve("/empty", "gzip")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get(harmony.HeaderContentEncoding))
+	assert.Empty(t, rec.Body.String())
+
+	// Flushing compresses whatever the length
+	rec = serve("/stream", "gzip")
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "gzip", rec.Header().Get(harmony.HeaderContentEncoding))
+	assert.Equal(t, "chunkchunk", gunzip(rec))
+
+	// The error handler writes through the original writer
+	rec = serve("/error", "gzip")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Empty(t, rec.Header().Get(harmony.HeaderContentEncoding))
+	assert.JSONEq(t, `{"message":"Not Found"}`, rec.Body.String())
+
+	rec = serve("/long", "")
+	assert.Empty(t, rec.Header().Get(harmony.HeaderContentEncoding))
+	assert.Equal(t, harmony.HeaderAcceptEncoding, rec.Header().Get(harmony.HeaderVary))
+	assert.Equal(t, body, rec.Body.String())
+
+	// The strong ETag of a file is weakened when the file is compressed
+	rec = serve("/file", "gzip")
+	assert.Equal(t, "gzip", rec.Header().Get(harmony.HeaderContentEncoding))
+	etag := rec.Header().Get(harmony.HeaderETag)
+	assert.True(t, strings.HasPrefix(etag, `W/"`), etag)
+	assert.Equal(t, body, gunzip(rec))
+
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set(harmony.HeaderAcceptEncoding, "gzip")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// Partial responses are not compressed
+	req = httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set(harmony.HeaderAcceptEncoding, "gzip")
+	req.Header.Set("Range", "bytes=0-9")
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Empty(t, rec.Header().Get(harmony.HeaderContentEncoding))
+	assert.Equal(t, "bytes 0-9/256", rec.Header().Get(harmony.HeaderContentRange))
+	assert.Equal(t, body[:10], rec.Body.String())
+	assert.False(t, strings.HasPrefix(rec.Header().Get(harmony.HeaderETag), "W/"))
+}
@@ -31,12 +31,6 @@ type (
 		// - {latency}
 		Format string
 	}
-
-	loggerResponseWriter struct {
-		http.ResponseWriter
-		code    int
-		latency time.Duration
-	}
 )
 
 // Logger returns a middleware which logs HTTP requests.
@@ -49,6 +43,12 @@ func Logger(loggerCfg ...*LoggerConfig) harmony.MiddlewareFunc {
 	if len(loggerCfg) > 0 {
 		cfg = loggerCfg[0]
 	}
+	if cfg.Skipper == nil {
+		cfg.Skipper = defaultSkipper
+	}
+	if cfg.Format == "" {
+		cfg.Format = defaultLoggerFormat
+	}
 
 	return func(next harmony.HandlerFunc) harmony.HandlerFunc {
 		return func(ctx harmony.Context) error {
@@ -56,28 +56,32 @@ func Logger(loggerCfg ...*LoggerConfig) harmony.MiddlewareFunc {
 				return next(ctx)
 			}
 
-			req := ctx.Request()
-			cfg.Format = strings.ReplaceAll(cfg.Format, "{remote_ip}", req.RemoteAddr)
-			cfg.Format = strings.ReplaceAll(cfg.Format, "{host}", req.Host)
-			cfg.Format = strings.ReplaceAll(cfg.Format, "{method}", req.Method)
-			cfg.Format = strings.ReplaceAll(cfg.Format, "{path}", req.URL.Path)
-			cfg.Format = strings.ReplaceAll(cfg.Format, "{protocol}", req.Proto)
+			start := time.Now()
+			err := next(ctx)
 
-			lrw := &loggerResponseWriter{ResponseWriter: ctx.ResponseWriter()}
-			ctx.SetResponseWriter(lrw)
-			_ = next(ctx)
-			cfg.Format = strings.ReplaceAll(cfg.Format, "{status}", strconv.Itoa(lrw.code))
-			cfg.Format = strings.ReplaceAll(cfg.Format, "{latency}", lrw.latency.String())
-			log.Println(cfg.Format)
-			return nil
+			req := ctx.Request()
+			log.Println(strings.NewReplacer(
+				"{remote_ip}", ctx.RealIP(),
+				"{host}", req.Host,
+				"{method}", req.Method,
+				"{path}", req.URL.Path,
+				"{protocol}", ctx.Protocol(),
+				"{status}", strconv.Itoa(loggedStatus(ctx.Response(), err)),
+				"{latency}", time.Since(start).String(),
+			).Replace(cfg.Format))
+			return err
 		}
 	}
 }
 
-// WriteHeader implements http.ResponseWriter.
-func (w *loggerResponseWriter) WriteHeader(code int) {
-	now := time.Now()
-	w.code = code
-	w.ResponseWriter.WriteHeader(code)
-	w.latency = time.Since(now)
+// loggedStatus returns the status of the response. When nothing has been
+// written yet, it is harmony.ErrorStatus of err, or 200 OK written by net/http.
+func loggedStatus(res *harmony.Response, err error) int {
+	if res.Committed {
+		return res.Status
+	}
+	if err == nil {
+		return http.StatusOK
+	}
+	return harmony.ErrorStatus(err)
 }
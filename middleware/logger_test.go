@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"github.com/SyntaxCrew/harmony"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	app := harmony.New()
+	app.Use(Logger(&LoggerConfig{
+		Skipper: defaultSkipper,
+		Format:  "{remote_ip} {method} {path} {protocol} {status}",
+	}))
+	app.Get("/users", func(ctx harmony.Context) error {
+		return ctx.SendStatus(http.StatusNoContent)
+	})
+	app.Post("/users", func(ctx harmony.Context) error {
+		return ctx.SendStatus(http.StatusCreated)
+	})
+	app.Delete("/users", func(ctx harmony.Context) error {
+		return harmony.ErrNotFound
+	})
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", nil))
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/users", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		"192.0.2.1 GET /users HTTP/1.1 204",
+		"192.0.2.1 POST /users HTTP/1.1 201",
+		"192.0.2.1 DELETE /users HTTP/1.1 404",
+	}, lines)
+}
+
+func TestLogger_Error(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	handlerErr := errors.New("database unreachable")
+	var handled []error
+	app := harmony.New()
+	app.HTTPErrorHandler = func(err error, ctx harmony.Context) {
+		handled = append(handled, err)
+		harmony.DefaultHTTPErrorHandler(err, ctx)
+	}
+	app.Use(Logger())
+	app.Get("/users/:id", func(ctx harmony.Context) error {
+		return harmony.NewHTTPError(http.StatusNotFound, "user not found")
+	})
+	app.Get("/health", func(ctx harmony.Context) error {
+		return handlerErr
+	})
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"message":"user not found"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Len(t, handled, 2)
+	assert.ErrorIs(t, handled[1], handlerErr)
+}
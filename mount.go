@@ -0,0 +1,104 @@
+package harmony
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mount serves handler under prefix for every HTTP method. The prefix is stripped
+// from the request path, and the path params matched by the prefix are available
+// with http.Request.PathValue. The global middlewares run before handler.
+func (h *Harmony) Mount(prefix string, handler http.Handler, middlewares ...MiddlewareFunc) []*Route {
+	return mount(h.Any, prefix, mountHandler(handler), middlewares)
+}
+
+// MountApp serves app under prefix for every HTTP method. The prefix is stripped
+// from the request path, and the path params matched by the prefix are kept on the
+// Context. The global middlewares of Harmony run before the ones of app.
+//
+// While app serves the request, the Context is bound to app: its Config applies,
+// its HTTPErrorHandler handles the errors, and Context.URL builds the URL of its
+// named routes under the prefix.
+func (h *Harmony) MountApp(prefix string, app *Harmony, middlewares ...MiddlewareFunc) []*Route {
+	return mount(h.Any, prefix, mountAppHandler(app), middlewares)
+}
+
+// Mount serves handler under the group prefix and prefix. See Harmony.Mount.
+func (g *Group) Mount(prefix string, handler http.Handler, middlewares ...MiddlewareFunc) []*Route {
+	return mount(g.Any, prefix, mountHandler(handler), middlewares)
+}
+
+// MountApp serves app under the group prefix and prefix. See Harmony.MountApp.
+func (g *Group) MountApp(prefix string, app *Harmony, middlewares ...MiddlewareFunc) []*Route {
+	return mount(g.Any, prefix, mountAppHandler(app), middlewares)
+}
+
+// mount registers handlerFunc with add for prefix and every path under it.
+func mount(
+	add func(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) []*Route,
+	prefix string,
+	handlerFunc HandlerFunc,
+	middlewares []MiddlewareFunc,
+) []*Route {
+	prefix = strings.TrimSuffix(prefix, "/")
+	routes := add(prefix+"/*", handlerFunc, middlewares...)
+	if prefix != "" {
+		routes = append(routes, add(prefix, handlerFunc, middlewares...)...)
+	}
+	return routes
+}
+
+func mountHandler(handler http.Handler) HandlerFunc {
+	return func(ctx Context) error {
+		r := stripPrefix(ctx)
+		for _, p := range *ctx.pathParams() {
+			r.SetPathValue(p.Key, p.Value)
+		}
+		handler.ServeHTTP(ctx.ResponseWriter(), r)
+		return nil
+	}
+}
+
+func mountAppHandler(app *Harmony) HandlerFunc {
+	return func(ctx Context) error {
+		r := ctx.Request()
+		h, prefix := ctx.binding()
+		stripped := stripPrefix(ctx)
+		ctx.setRequest(stripped)
+		ctx.bind(app, prefix+mountPrefix(r.URL.Path, stripped.URL.Path))
+		defer func() {
+			ctx.setRequest(r)
+			ctx.bind(h, prefix)
+		}()
+
+		app.freezeOnce.Do(app.freeze)
+		if err := (*app.handler.Load())(ctx); err != nil {
+			app.HTTPErrorHandler(err, ctx)
+		}
+		return nil
+	}
+}
+
+// mountPrefix returns the escaped prefix of path, which is served as rest by
+// a mounted app.
+func mountPrefix(path, rest string) string {
+	prefix := strings.TrimSuffix(strings.TrimSuffix(path, strings.TrimPrefix(rest, "/")), "/")
+	return (&url.URL{Path: prefix}).EscapedPath()
+}
+
+// stripPrefix pops the wildcard param matched by a mount route and returns
+// a copy of the request with the rest of the path as URL path.
+func stripPrefix(ctx Context) *http.Request {
+	var rest string
+	params := ctx.pathParams()
+	if n := len(*params); n > 0 && (*params)[n-1].Key == "*" {
+		rest = (*params)[n-1].Value
+		*params = (*params)[:n-1]
+	}
+
+	r := ctx.Request().Clone(ctx.Request().Context())
+	r.URL.Path = "/" + rest
+	r.URL.RawPath = ""
+	return r
+}
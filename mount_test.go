@@ -0,0 +1,126 @@
+package harmony
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHarmony_Mount(t *testing.T) {
+	app := New()
+	buf := bytes.NewBuffer([]byte{})
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			buf.WriteString(ctx.Request().URL.Path + ";")
+			return next(ctx)
+		}
+	})
+	app.Mount("/tenants/:tenant/legacy", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.PathValue("tenant")))
+	}))
+
+	recCode, recBody := newRequest(http.MethodPost, "/tenants/acme/legacy/users/1", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "POST /users/1 acme", recBody)
+
+	recCode, recBody = newRequest(http.MethodGet, "/tenants/acme/legacy", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "GET / acme", recBody)
+
+	assert.Equal(t, "/tenants/acme/legacy/users/1;/tenants/acme/legacy;", buf.String())
+}
+
+func TestHarmony_MountApp(t *testing.T) {
+	users := New()
+	users.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			ctx.Set("module", "users")
+			return next(ctx)
+		}
+	})
+	users.Get("/:id", func(ctx Context) error {
+		return ctx.String(http.StatusOK, ctx.Get("app").(string)+" "+ctx.Get("module").(string)+" "+ctx.PathParam("tenant")+" "+ctx.PathParam("id"))
+	})
+
+	app := New()
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			ctx.Set("app", "root")
+			return next(ctx)
+		}
+	})
+	app.Group("/tenants/:tenant").MountApp("/users", users)
+
+	recCode, recBody := newRequest(http.MethodGet, "/tenants/acme/users/1", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "root users acme 1", recBody)
+
+	recCode, _ = newRequest(http.MethodGet, "/tenants/acme/users/1/posts", app)
+	assert.Equal(t, http.StatusNotFound, recCode)
+
+	recCode, _ = newRequest(http.MethodDelete, "/tenants/acme/users/1", app)
+	assert.Equal(t, http.StatusMethodNotAllowed, recCode)
+}
+
+func TestHarmony_MountAppContext(t *testing.T) {
+	users := New(&Config{SafeRedirects: true})
+	users.HTTPErrorHandler = func(err error, ctx Context) {
+		_ = ctx.String(http.StatusTeapot, "users: "+err.Error())
+	}
+	users.Get("/:id", testHandler).Name("user.show")
+	users.Get("/:id/url", func(ctx Context) error {
+		url, err := ctx.URL("user.show", ctx.PathParam("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.String(http.StatusOK, url)
+	})
+	users.Get("/:id/redirect", func(ctx Context) error {
+		return ctx.RedirectToRoute(http.StatusFound, "user.show", ctx.PathParam("id"))
+	})
+	users.Get("/external", func(ctx Context) error {
+		return ctx.Redirect(http.StatusFound, "https://evil.test")
+	})
+	users.Get("/fail", func(ctx Context) error {
+		return NewHTTPError(http.StatusBadRequest, "bad")
+	})
+
+	app := New()
+	app.Get("/", testHandler).Name("home")
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			err := next(ctx)
+			// The Context is bound to app again after the mounted app
+			url, urlErr := ctx.URL("home")
+			assert.NoError(t, urlErr)
+			assert.Equal(t, "/", url)
+			return err
+		}
+	})
+	app.Group("/tenants/:tenant").MountApp("/users", users)
+
+	recCode, recBody := newRequest(http.MethodGet, "/tenants/a%20b/users/1/url", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "/tenants/a%20b/users/1", recBody)
+
+	req := httptest.NewRequest(http.MethodGet, "/tenants/acme/users/1/redirect", nil)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/tenants/acme/users/1", rec.Header().Get(HeaderLocation))
+
+	// The Config and the HTTPErrorHandler of the mounted app apply
+	recCode, recBody = newRequest(http.MethodGet, "/tenants/acme/users/external", app)
+	assert.Equal(t, http.StatusTeapot, recCode)
+	assert.Equal(t, "users: harmony: code=400, message=unsafe redirect", recBody)
+
+	recCode, recBody = newRequest(http.MethodGet, "/tenants/acme/users/fail", app)
+	assert.Equal(t, http.StatusTeapot, recCode)
+	assert.Equal(t, "users: harmony: code=400, message=bad", recBody)
+
+	recCode, recBody = newRequest(http.MethodGet, "/tenants/acme/users/1/posts", app)
+	assert.Equal(t, http.StatusTeapot, recCode)
+	assert.Equal(t, "users: harmony: code=404, message=Not Found", recBody)
+}
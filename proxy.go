@@ -0,0 +1,205 @@
+package harmony
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// proxyV1MaxLen is the maximum length of a PROXY protocol v1 header.
+	proxyV1MaxLen = 107
+
+	// proxyV2HeaderLen is the length of the fixed part of a PROXY protocol v2 header.
+	proxyV2HeaderLen = 16
+)
+
+var (
+	// proxyV2Signature starts every PROXY protocol v2 header.
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	// ErrInvalidProxyHeader is returned when reading a connection without a
+	// valid PROXY protocol header.
+	ErrInvalidProxyHeader = errors.New("harmony: invalid PROXY protocol header")
+)
+
+type (
+	// proxyListener is a listener whose connections start with a PROXY protocol header.
+	proxyListener struct {
+		net.Listener
+		timeout time.Duration
+	}
+
+	// proxyConn reads the PROXY protocol header on first use and reports the
+	// addresses it carries as its remote and local addresses.
+	proxyConn struct {
+		net.Conn
+		r       *bufio.Reader
+		timeout time.Duration
+		once    sync.Once
+		err     error
+		src     net.Addr
+		dst     net.Addr
+	}
+)
+
+// Accept implements net.Listener. The header is read later by the connection
+// goroutine, so that a slow client cannot block the accept loop.
+func (l *proxyListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{Conn: c, r: bufio.NewReaderSize(c, 256), timeout: l.timeout}, nil
+}
+
+// Read implements net.Conn.
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.r.Buffered() > 0 {
+		return c.r.Read(b)
+	}
+	return c.Conn.Read(b)
+}
+
+// RemoteAddr returns the source address of the PROXY protocol header.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.src != nil {
+		return c.src
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr returns the destination address of the PROXY protocol header.
+func (c *proxyConn) LocalAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.dst != nil {
+		return c.dst
+	}
+	return c.Conn.LocalAddr()
+}
+
+func (c *proxyConn) readHeader() {
+	if c.timeout > 0 {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		defer func() {
+			_ = c.Conn.SetReadDeadline(time.Time{})
+		}()
+	}
+
+	b, err := c.r.Peek(1)
+	switch {
+	case err != nil:
+		c.err = err
+	case b[0] == 'P':
+		c.src, c.dst, c.err = readProxyV1(c.r)
+	default:
+		c.src, c.dst, c.err = readProxyV2(c.r)
+	}
+	if c.err != nil {
+		_ = c.Conn.Close()
+	}
+}
+
+// readProxyV1 reads a PROXY protocol v1 header, such as
+// "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n".
+// The addresses are nil for the UNKNOWN protocol.
+func readProxyV1(r *bufio.Reader) (src, dst net.Addr, err error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil || len(line) > proxyV1MaxLen || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, ErrInvalidProxyHeader
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if fields[0] != "PROXY" || len(fields) < 2 {
+		return nil, nil, ErrInvalidProxyHeader
+	}
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil, nil, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, nil, ErrInvalidProxyHeader
+	}
+	if len(fields) != 6 {
+		return nil, nil, ErrInvalidProxyHeader
+	}
+
+	src, err = parseProxyV1Addr(fields[2], fields[4])
+	if err != nil {
+		return nil, nil, err
+	}
+	dst, err = parseProxyV1Addr(fields[3], fields[5])
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, dst, nil
+}
+
+func parseProxyV1Addr(host, port string) (net.Addr, error) {
+	ip := net.ParseIP(host)
+	p, err := strconv.ParseUint(port, 10, 16)
+	if ip == nil || err != nil {
+		return nil, ErrInvalidProxyHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(p)}, nil
+}
+
+// readProxyV2 reads a binary PROXY protocol v2 header. The addresses are nil
+// for the LOCAL command and for address families other than TCP and UDP over
+// IPv4 and IPv6.
+func readProxyV2(r *bufio.Reader) (src, dst net.Addr, err error) {
+	hdr := make([]byte, proxyV2HeaderLen)
+	if _, err := io.ReadFull(r, hdr); err != nil || !bytes.HasPrefix(hdr, proxyV2Signature) {
+		return nil, nil, ErrInvalidProxyHeader
+	}
+	if hdr[12]>>4 != 2 {
+		return nil, nil, ErrInvalidProxyHeader
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(hdr[14:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, ErrInvalidProxyHeader
+	}
+
+	switch hdr[12] & 0xf {
+	case 0x0: // LOCAL
+		return nil, nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, nil, ErrInvalidProxyHeader
+	}
+
+	var ipLen int
+	switch hdr[13] >> 4 {
+	case 0x1: // AF_INET
+		ipLen = net.IPv4len
+	case 0x2: // AF_INET6
+		ipLen = net.IPv6len
+	default:
+		return nil, nil, nil
+	}
+	if len(payload) < 2*ipLen+4 {
+		return nil, nil, ErrInvalidProxyHeader
+	}
+
+	srcIP := net.IP(payload[:ipLen])
+	dstIP := net.IP(payload[ipLen : 2*ipLen])
+	srcPort := int(binary.BigEndian.Uint16(payload[2*ipLen:]))
+	dstPort := int(binary.BigEndian.Uint16(payload[2*ipLen+2:]))
+	if hdr[13]&0xf == 0x2 { // DGRAM
+		return &net.UDPAddr{IP: srcIP, Port: srcPort}, &net.UDPAddr{IP: dstIP, Port: dstPort}, nil
+	}
+	return &net.TCPAddr{IP: srcIP, Port: srcPort}, &net.TCPAddr{IP: dstIP, Port: dstPort}, nil
+}
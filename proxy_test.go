@@ -0,0 +1,138 @@
+package harmony
+
+import (
+	"bufio"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadProxyV1(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\nGET"))
+	src, dst, err := readProxyV1(r)
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.1:56324", src.String())
+	assert.Equal(t, "198.51.100.1:443", dst.String())
+	rest, _ := io.ReadAll(r)
+	assert.Equal(t, "GET", string(rest))
+
+	src, dst, err = readProxyV1(bufio.NewReader(strings.NewReader("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n")))
+	require.NoError(t, err)
+	assert.Equal(t, "[2001:db8::1]:56324", src.String())
+	assert.Equal(t, "[2001:db8::2]:443", dst.String())
+
+	src, dst, err = readProxyV1(bufio.NewReader(strings.NewReader("PROXY UNKNOWN\r\n")))
+	require.NoError(t, err)
+	assert.Nil(t, src)
+	assert.Nil(t, dst)
+
+	for _, header := range []string{
+		"PROXY TCP4 192.0.2.1 198.51.100.1 56324\r\n",
+		"PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\n",
+		"PROXY TCP4 invalid 198.51.100.1 56324 443\r\n",
+		"PROXY TCP4 192.0.2.1 198.51.100.1 56324 65536\r\n",
+		"PROXY UDP4 192.0.2.1 198.51.100.1 56324 443\r\n",
+		"PRIXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n",
+		"PROXY TCP4 " + strings.Repeat("1", 100) + "\r\n",
+	} {
+		_, _, err := readProxyV1(bufio.NewReader(strings.NewReader(header)))
+		assert.ErrorIs(t, err, ErrInvalidProxyHeader, header)
+	}
+}
+
+func TestReadProxyV2(t *testing.T) {
+	// PROXY command, TCP over IPv4, followed by a TLV
+	header := proxyV2Header(0x21, 0x11,
+		[]byte{192, 0, 2, 1}, []byte{198, 51, 100, 1}, 56324, 443,
+		[]byte{0x04, 0x00, 0x01, 0xff},
+	)
+	r := bufio.NewReader(strings.NewReader(string(header) + "GET"))
+	src, dst, err := readProxyV2(r)
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.1:56324", src.String())
+	assert.Equal(t, "198.51.100.1:443", dst.String())
+	rest, _ := io.ReadAll(r)
+	assert.Equal(t, "GET", string(rest))
+
+	// PROXY command, UDP over IPv6
+	header = proxyV2Header(0x21, 0x22, net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2"), 53, 53, nil)
+	src, dst, err = readProxyV2(bufio.NewReader(strings.NewReader(string(header))))
+	require.NoError(t, err)
+	assert.Equal(t, "udp", src.Network())
+	assert.Equal(t, "[2001:db8::1]:53", src.String())
+	assert.Equal(t, "[2001:db8::2]:53", dst.String())
+
+	// LOCAL command, sent by health checks of the load balancer
+	header = proxyV2Header(0x20, 0x00, nil, nil, 0, 0, nil)
+	src, dst, err = readProxyV2(bufio.NewReader(strings.NewReader(string(header))))
+	require.NoError(t, err)
+	assert.Nil(t, src)
+	assert.Nil(t, dst)
+
+	for _, header := range [][]byte{
+		proxyV2Header(0x11, 0x11, []byte{192, 0, 2, 1}, []byte{198, 51, 100, 1}, 1, 2, nil),
+		proxyV2Header(0x22, 0x11, []byte{192, 0, 2, 1}, []byte{198, 51, 100, 1}, 1, 2, nil),
+		proxyV2Header(0x21, 0x21, []byte{192, 0, 2, 1}, []byte{198, 51, 100, 1}, 1, 2, nil),
+		append([]byte("\r\n\r\n\x00\r\nQUIX\n"), 0x21, 0x11, 0, 0),
+	} {
+		_, _, err := readProxyV2(bufio.NewReader(strings.NewReader(string(header))))
+		assert.ErrorIs(t, err, ErrInvalidProxyHeader)
+	}
+}
+
+func TestHarmony_ProxyProtocol(t *testing.T) {
+	app := New(&Config{ProxyProtocol: true, HideBanner: true})
+	app.Get("/", func(ctx Context) error {
+		return ctx.String(http.StatusOK, ctx.Request().RemoteAddr+" "+ctx.RealIP())
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = app.Serve(l)
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, "PROXY TCP4 203.0.113.7 10.0.0.1 56324 8080\r\nGET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	require.NoError(t, err)
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "203.0.113.7:56324 203.0.113.7", string(body))
+
+	// Connections without header are closed
+	conn, err = net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	require.NoError(t, err)
+	_, err = http.ReadResponse(bufio.NewReader(conn), nil)
+	assert.Error(t, err)
+}
+
+func proxyV2Header(verCmd, family byte, src, dst net.IP, srcPort, dstPort uint16, tlv []byte) []byte {
+	if ip4 := src.To4(); family>>4 == 0x1 && ip4 != nil {
+		src, dst = ip4, dst.To4()
+	}
+	payload := append(append([]byte{}, src...), dst...)
+	if src != nil {
+		payload = binary.BigEndian.AppendUint16(payload, srcPort)
+		payload = binary.BigEndian.AppendUint16(payload, dstPort)
+	}
+	payload = append(payload, tlv...)
+
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, verCmd, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	return append(header, payload...)
+}
@@ -0,0 +1,96 @@
+package harmony
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// realIP returns the IP address of the client of r. The proxy headers are
+// only read when the peer of the connection is trusted, either in trusted or
+// on a Unix socket with trustUnix, and the address of the client is the last
+// hop not in trusted.
+func realIP(r *http.Request, trusted []netip.Prefix, trustUnix bool) string {
+	peer := parseIP(r.RemoteAddr)
+	if !trustUnix || !isUnixRequest(r) {
+		if !peer.IsValid() {
+			return r.RemoteAddr
+		}
+		if !isTrusted(peer, trusted) {
+			return peer.String()
+		}
+	}
+
+	var hops []string
+	if values := r.Header.Values(HeaderXForwardedFor); len(values) > 0 {
+		for _, v := range values {
+			hops = append(hops, strings.Split(v, ",")...)
+		}
+	} else if values := r.Header.Values(HeaderForwarded); len(values) > 0 {
+		hops = forwardedFor(values)
+	} else if ip := parseIP(r.Header.Get(HeaderXRealIP)); ip.IsValid() {
+		return ip.String()
+	}
+
+	addr := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIP(hops[i])
+		if !ip.IsValid() {
+			break
+		}
+		addr = ip
+		if !isTrusted(ip, trusted) {
+			break
+		}
+	}
+	if !addr.IsValid() {
+		return r.RemoteAddr
+	}
+	return addr.String()
+}
+
+// forwardedFor returns the for= parameters of the Forwarded header values.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, elem := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, value)
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseIP parses an IP address with an optional port, brackets and quotes.
+func parseIP(s string) netip.Addr {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap()
+	}
+	ip, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}
+	}
+	return ip.Unmap()
+}
+
+// isTrusted reports whether ip belongs to one of the trusted networks.
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, p := range trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnixRequest reports whether r was received on a Unix socket.
+func isUnixRequest(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && addr.Network() == "unix"
+}
@@ -0,0 +1,127 @@
+package harmony
+
+import (
+	gocontext "context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestContext_RealIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8:1::/48"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{
+			name:       "untrusted peer",
+			remoteAddr: "203.0.113.7:1234",
+			header:     http.Header{HeaderXForwardedFor: {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted peer without header",
+			remoteAddr: "10.0.0.1:1234",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{HeaderXForwardedFor: {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "X-Forwarded-For spoofed by the client",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{HeaderXForwardedFor: {"192.0.2.66, 198.51.100.1, 10.0.0.2"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "X-Forwarded-For over multiple headers",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{HeaderXForwardedFor: {"198.51.100.1", "10.0.0.2"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "X-Forwarded-For with trusted hops only",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{HeaderXForwardedFor: {"10.0.0.3, 10.0.0.2"}},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "X-Forwarded-For with invalid hop",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{HeaderXForwardedFor: {"198.51.100.1, unknown, 10.0.0.2"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "Forwarded",
+			remoteAddr: "[2001:db8:1::1]:1234",
+			header: http.Header{HeaderForwarded: {
+				`for=192.0.2.66;proto=https, For="[2001:db8:cafe::17]:4711";by=10.0.0.1`,
+			}},
+			want: "2001:db8:cafe::17",
+		},
+		{
+			name:       "X-Real-IP",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{HeaderXRealIP: {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "IPv4-mapped IPv6 peer",
+			remoteAddr: "[::ffff:10.0.0.1]:1234",
+			header:     http.Header{HeaderXRealIP: {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			app := New(&Config{TrustedProxies: trusted})
+			app.Get("/", func(ctx Context) error {
+				got = ctx.RealIP()
+				return nil
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, values := range tt.header {
+				for _, v := range values {
+					req.Header.Add(k, v)
+				}
+			}
+			app.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestContext_RealIP_NoTrustedProxies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set(HeaderXForwardedFor, "198.51.100.1")
+	assert.Equal(t, "10.0.0.1", realIP(req, nil, true))
+}
+
+func TestContext_RealIP_UnixSocket(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "@"
+	req = req.WithContext(gocontext.WithValue(req.Context(), http.LocalAddrContextKey, &net.UnixAddr{Name: "app.sock", Net: "unix"}))
+	req.Header.Set(HeaderXForwardedFor, "198.51.100.1")
+	assert.Equal(t, "198.51.100.1", realIP(req, nil, true))
+
+	// Unix socket peers are not trusted by default
+	assert.Equal(t, "@", realIP(req, nil, false))
+
+	req.Header.Del(HeaderXForwardedFor)
+	assert.Equal(t, "@", realIP(req, nil, true))
+}
@@ -0,0 +1,49 @@
+package harmony
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// safeRedirect reports whether target stays on host, the host of the request,
+// or goes to one of the allowed hosts. Targets that browsers may read as
+// another host than url.Parse does are rejected: "//evil.com" and "/\evil.com"
+// are protocol-relative, and browsers treat backslashes as slashes and strip
+// whitespace and control characters.
+func safeRedirect(target, host string, allowed []string) bool {
+	if strings.TrimSpace(target) != target || strings.ContainsRune(target, '\\') {
+		return false
+	}
+	for _, r := range target {
+		if r < ' ' || r == 0x7f {
+			return false
+		}
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return !strings.HasPrefix(target, "//")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+
+	target = strings.ToLower(u.Hostname())
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if target == strings.ToLower(strings.Trim(host, "[]")) {
+		return true
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if target == a || strings.HasPrefix(a, "*.") && strings.HasSuffix(target, a[1:]) {
+			return true
+		}
+	}
+	return false
+}
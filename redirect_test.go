@@ -0,0 +1,116 @@
+package harmony
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSafeRedirect(t *testing.T) {
+	allowed := []string{"accounts.example.org", "*.example.net"}
+	tests := []struct {
+		target string
+		safe   bool
+	}{
+		{target: "/dashboard", safe: true},
+		{target: "/search?q=//evil.com", safe: true},
+		{target: "settings", safe: true},
+		{target: "http://example.com/login", safe: true},
+		{target: "https://EXAMPLE.com:8443/login", safe: true},
+		{target: "https://accounts.example.org/login", safe: true},
+		{target: "https://cdn.example.net/", safe: true},
+		{target: "https://example.net/", safe: false},
+		{target: "https://evil.com/", safe: false},
+		{target: "https://example.com.evil.com/", safe: false},
+		{target: "//evil.com", safe: false},
+		{target: "///evil.com", safe: false},
+		{target: "/\\evil.com", safe: false},
+		{target: "\\\\evil.com", safe: false},
+		{target: "https:\\\\evil.com", safe: false},
+		{target: "https:///evil.com", safe: false},
+		{target: "http:evil.com", safe: false},
+		{target: " //evil.com", safe: false},
+		{target: "/\t/evil.com", safe: false},
+		{target: "javascript:alert(1)", safe: false},
+		{target: "ftp://example.com/", safe: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.safe, safeRedirect(tt.target, "example.com:8080", allowed), tt.target)
+	}
+	assert.True(t, safeRedirect("http://[::1]/", "[::1]:8080", nil))
+}
+
+func TestContext_Redirect(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", writeStringOKHandler()).Name("user.show")
+	app.Get("/redirect", func(ctx Context) error {
+		return ctx.Redirect(http.StatusFound, ctx.QueryString("to"))
+	})
+	app.Get("/ok", func(ctx Context) error {
+		return ctx.Redirect(http.StatusOK, "/")
+	})
+	app.Post("/users", func(ctx Context) error {
+		return ctx.RedirectToRoute(http.StatusSeeOther, "user.show", 1)
+	})
+	app.Post("/missing", func(ctx Context) error {
+		return ctx.RedirectToRoute(http.StatusSeeOther, "missing")
+	})
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/redirect?to=https://evil.com/", nil))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "https://evil.com/", rec.Header().Get(HeaderLocation))
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderLocation))
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/users/1", rec.Header().Get(HeaderLocation))
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/missing", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	for _, code := range []int{http.StatusContinue, http.StatusNotModified, http.StatusUseProxy, 306, http.StatusBadRequest} {
+		assert.ErrorIs(t, newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)).
+			Redirect(code, "/"), ErrInvalidRedirectCode, code)
+	}
+	for _, code := range []int{http.StatusMultipleChoices, http.StatusSeeOther, http.StatusPermanentRedirect} {
+		rec := httptest.NewRecorder()
+		assert.NoError(t, newContext(rec, httptest.NewRequest(http.MethodGet, "/", nil)).Redirect(code, "/"))
+		assert.Equal(t, code, rec.Code)
+	}
+}
+
+func TestContext_Redirect_Safe(t *testing.T) {
+	app := New(&Config{SafeRedirects: true, RedirectHosts: []string{"accounts.example.org"}})
+	app.Get("/login", func(ctx Context) error {
+		return ctx.Redirect(http.StatusFound, ctx.QueryString("next"))
+	})
+
+	serve := func(next string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/login", nil)
+		r.URL.RawQuery = "next=" + next
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve("/dashboard")
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/dashboard", rec.Header().Get(HeaderLocation))
+
+	rec = serve("https://accounts.example.org/")
+	assert.Equal(t, http.StatusFound, rec.Code)
+
+	for _, next := range []string{"https://evil.com/", "//evil.com", "/%5Cevil.com"} {
+		rec = serve(next)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, next)
+		assert.Empty(t, rec.Header().Get(HeaderLocation), next)
+	}
+}
@@ -0,0 +1,166 @@
+package harmony
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+type (
+	// Response wraps an http.ResponseWriter and tracks the status, size and
+	// committed state of the response. It is returned by Context.Response.
+	Response struct {
+		// Writer is the underlying http.ResponseWriter. Middlewares may replace
+		// it, for example to compress the body, and restore it when done.
+		Writer http.ResponseWriter
+
+		// Status is the status code of the response, 0 until the header is written.
+		Status int
+
+		// Size is the number of body bytes written by the handler.
+		Size int64
+
+		// Committed reports whether the header has been written.
+		Committed bool
+
+		beforeFuncs []func()
+		afterFuncs  []func()
+	}
+
+	// headResponseWriter discards the body written by a GET handler answering
+	// a HEAD request and delays the header to report the body's Content-Length.
+	headResponseWriter struct {
+		http.ResponseWriter
+		code        int
+		size        int
+		wroteHeader bool
+		flushed     bool
+	}
+)
+
+// NewResponse returns a new Response writing to w.
+func NewResponse(w http.ResponseWriter) *Response {
+	return &Response{Writer: w}
+}
+
+// Header implements http.ResponseWriter.
+func (r *Response) Header() http.Header {
+	return r.Writer.Header()
+}
+
+// Before registers fn to run right before the header is written.
+func (r *Response) Before(fn func()) {
+	r.beforeFuncs = append(r.beforeFuncs, fn)
+}
+
+// After registers fn to run after each write of the body.
+func (r *Response) After(fn func()) {
+	r.afterFuncs = append(r.afterFuncs, fn)
+}
+
+// WriteHeader implements http.ResponseWriter. The header is only written once.
+func (r *Response) WriteHeader(code int) {
+	if r.Committed {
+		return
+	}
+	for _, fn := range r.beforeFuncs {
+		fn()
+	}
+	r.Status = code
+	r.Committed = true
+	r.Writer.WriteHeader(code)
+}
+
+// Write implements io.Writer.
+func (r *Response) Write(b []byte) (int, error) {
+	if !r.Committed {
+		r.WriteHeader(http.StatusOK)
+	}
+	n, err := r.Writer.Write(b)
+	r.Size += int64(n)
+	for _, fn := range r.afterFuncs {
+		fn()
+	}
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (r *Response) Flush() {
+	if !r.Committed {
+		r.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(r.Writer).Flush()
+}
+
+// Hijack implements http.Hijacker.
+func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.Writer).Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (r *Response) Unwrap() http.ResponseWriter {
+	return r.Writer
+}
+
+func (r *Response) reset(w http.ResponseWriter) {
+	r.Writer = w
+	r.Status = 0
+	r.Size = 0
+	r.Committed = false
+	r.beforeFuncs = r.beforeFuncs[:0]
+	r.afterFuncs = r.afterFuncs[:0]
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *headResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.code = code
+	w.wroteHeader = true
+}
+
+// Write implements io.Writer.
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.size += len(b)
+	return len(b), nil
+}
+
+// Flush implements http.Flusher.
+func (w *headResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.flush()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the delayed header, if any, with the size of the discarded body.
+func (w *headResponseWriter) finish() {
+	if !w.wroteHeader {
+		return
+	}
+	if w.size > 0 && w.Header().Get(HeaderContentLength) == "" {
+		w.Header().Set(HeaderContentLength, strconv.Itoa(w.size))
+	}
+	w.flush()
+}
+
+func (w *headResponseWriter) flush() {
+	if w.flushed {
+		return
+	}
+	w.flushed = true
+	w.ResponseWriter.WriteHeader(w.code)
+}
@@ -0,0 +1,97 @@
+package harmony
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	res := NewResponse(rec)
+
+	var calls []string
+	res.Before(func() {
+		calls = append(calls, "before")
+		res.Header().Set("X-Before", "1")
+	})
+	res.After(func() {
+		calls = append(calls, "after")
+	})
+
+	res.WriteHeader(http.StatusCreated)
+	res.WriteHeader(http.StatusInternalServerError)
+	n, err := res.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	_, _ = res.Write([]byte(", world"))
+
+	assert.True(t, res.Committed)
+	assert.Equal(t, http.StatusCreated, res.Status)
+	assert.Equal(t, int64(12), res.Size)
+	assert.Equal(t, []string{"before", "after", "after"}, calls)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Before"))
+	assert.Equal(t, "hello, world", rec.Body.String())
+	assert.Same(t, rec, res.Unwrap().(*httptest.ResponseRecorder))
+
+	res.reset(httptest.NewRecorder())
+	assert.False(t, res.Committed)
+	assert.Zero(t, res.Status)
+	assert.Zero(t, res.Size)
+}
+
+func TestResponse_Flush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	res := NewResponse(rec)
+	res.Flush()
+	assert.True(t, res.Committed)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.True(t, rec.Flushed)
+}
+
+func TestResponse_Hijack(t *testing.T) {
+	app := New(&Config{HideBanner: true})
+	app.Get("/", func(ctx Context) error {
+		conn, rw, err := ctx.Response().Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		return rw.Flush()
+	})
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	code, body := getBody(t, http.DefaultClient, srv.URL)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "hijacked", body)
+
+	// The recorder does not support hijacking
+	_, _, err := NewResponse(httptest.NewRecorder()).Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+}
+
+func TestContext_Response(t *testing.T) {
+	app := New()
+	app.Get("/", func(ctx Context) error {
+		assert.Same(t, ctx.Response(), ctx.ResponseWriter())
+		assert.False(t, ctx.Response().Committed)
+		if err := ctx.String(http.StatusAccepted, "OK"); err != nil {
+			return err
+		}
+		assert.Equal(t, http.StatusAccepted, ctx.Response().Status)
+		assert.Equal(t, int64(2), ctx.Response().Size)
+		return nil
+	})
+	code, body := newRequest(http.MethodGet, "/", app)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "OK", body)
+
+	code, body = newRequest(http.MethodHead, "/", app)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Empty(t, body)
+}
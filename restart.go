@@ -0,0 +1,61 @@
+package harmony
+
+import (
+	gocontext "context"
+	"net"
+	"os"
+	"strconv"
+)
+
+const (
+	// envListenFD holds the file descriptor of the listener passed by Restart.
+	envListenFD = "HARMONY_LISTEN_FD"
+
+	// envReadyFD holds the file descriptor the new process of Restart writes to once ready.
+	envReadyFD = "HARMONY_READY_FD"
+)
+
+// restartCommand returns the executable and arguments of the new process started by Restart.
+var restartCommand = func() (string, []string, error) {
+	exe, err := os.Executable()
+	return exe, os.Args[1:], err
+}
+
+// Restart starts a new process of the same executable with the same arguments,
+// passing it the listening socket, and waits until it is ready to accept
+// connections or ctx is done. The server is then shut down with Shutdown(ctx):
+// active connections are drained while the new process accepts the new ones.
+// Restart is only supported on Unix.
+func (h *Harmony) Restart(ctx gocontext.Context) error {
+	if err := h.handoff(ctx); err != nil {
+		return err
+	}
+	return h.Shutdown(ctx)
+}
+
+// inheritedListener returns the listener passed by the parent process of
+// Restart, or nil when the process was not started by Restart.
+func inheritedListener() (net.Listener, error) {
+	fd, err := strconv.Atoi(os.Getenv(envListenFD))
+	if err != nil {
+		return nil, nil
+	}
+	_ = os.Unsetenv(envListenFD)
+
+	f := os.NewFile(uintptr(fd), "harmony-listener")
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// notifyReady tells the parent process of Restart that the server accepts connections.
+func notifyReady() {
+	fd, err := strconv.Atoi(os.Getenv(envReadyFD))
+	if err != nil {
+		return
+	}
+	_ = os.Unsetenv(envReadyFD)
+
+	f := os.NewFile(uintptr(fd), "harmony-ready")
+	_, _ = f.Write([]byte{1})
+	_ = f.Close()
+}
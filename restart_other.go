@@ -0,0 +1,14 @@
+//go:build !unix
+
+package harmony
+
+import (
+	gocontext "context"
+	"errors"
+	"runtime"
+)
+
+// handoff is not supported on this platform.
+func (h *Harmony) handoff(gocontext.Context) error {
+	return errors.New("harmony: restart is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,110 @@
+//go:build unix
+
+package harmony
+
+import (
+	gocontext "context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// handoff starts the new process of Restart and waits until it is ready.
+// The new process is killed if it is not ready before ctx is done.
+func (h *Harmony) handoff(ctx gocontext.Context) error {
+	h.srvMu.Lock()
+	l, closed := h.ln, h.closed
+	h.srvMu.Unlock()
+	if l == nil || closed {
+		return errors.New("harmony: server is not running")
+	}
+
+	filer, ok := l.(interface{ File() (*os.File, error) })
+	if !ok {
+		return errors.New("harmony: listener cannot be passed to a new process")
+	}
+	lf, err := filer.File()
+	if err != nil {
+		return err
+	}
+	defer lf.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	exe, args, err := restartCommand()
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), envListenFD+"=3", envReadyFD+"=4")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{lf, w}
+	err = cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		return err
+	}
+
+	// Passing the socket put it in blocking mode, which the network poller does not expect
+	if err := setNonblock(l); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 1))
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+		if errors.Is(err, io.EOF) {
+			err = errors.New("harmony: new process exited before being ready")
+		}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
+	// The new process now owns the Unix socket file
+	if ul, ok := l.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+	return nil
+}
+
+// setNonblock puts the socket of l back in non-blocking mode.
+func setNonblock(l net.Listener) error {
+	sc, ok := l.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var nerr error
+	if err := rc.Control(func(fd uintptr) {
+		nerr = syscall.SetNonblock(int(fd), true)
+	}); err != nil {
+		return err
+	}
+	return nerr
+}
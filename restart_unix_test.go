@@ -0,0 +1,125 @@
+//go:build unix
+
+package harmony
+
+import (
+	gocontext "context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHarmony_Restart(t *testing.T) {
+	if os.Getenv(envListenFD) != "" {
+		// New process started by the parent test
+		app := New(&Config{Addr: "invalid", HideBanner: true})
+		app.Get("/", func(ctx Context) error {
+			return ctx.String(http.StatusOK, "child "+strconv.Itoa(os.Getpid()))
+		})
+		_ = app.ListenAndServe()
+		return
+	}
+
+	restartTestCommand(t, "^TestHarmony_Restart$")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	app := New(&Config{HideBanner: true})
+	app.Get("/", writeStringOKHandler())
+	app.Get("/slow", func(ctx Context) error {
+		close(started)
+		<-release
+		return ctx.String(http.StatusOK, "slow")
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	url := "http://" + l.Addr().String()
+	served := make(chan error, 1)
+	go func() {
+		served <- app.Serve(l)
+	}()
+
+	slow := make(chan string, 1)
+	go func() {
+		_, body := getBody(t, http.DefaultClient, url+"/slow")
+		slow <- body
+	}()
+	<-started
+
+	restarted := make(chan error, 1)
+	go func() {
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 10*time.Second)
+		defer cancel()
+		restarted <- app.Restart(ctx)
+	}()
+	assert.ErrorIs(t, <-served, http.ErrServerClosed)
+
+	// New connections are accepted by the child process
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	code, body := getBody(t, client, url+"/")
+	assert.Equal(t, http.StatusOK, code)
+	require.True(t, strings.HasPrefix(body, "child "), body)
+	pid, err := strconv.Atoi(strings.TrimPrefix(body, "child "))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if p, err := os.FindProcess(pid); err == nil {
+			_ = p.Kill()
+		}
+	})
+
+	// The active request of the parent is drained
+	close(release)
+	assert.Equal(t, "slow", <-slow)
+	assert.NoError(t, <-restarted)
+}
+
+func TestHarmony_Restart_NotReady(t *testing.T) {
+	if os.Getenv(envListenFD) != "" {
+		// New process failing to start
+		os.Exit(1)
+	}
+
+	restartTestCommand(t, "^TestHarmony_Restart_NotReady$")
+
+	app := New(&Config{HideBanner: true})
+	app.Get("/", writeStringOKHandler())
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = app.Serve(l)
+	}()
+	require.Eventually(t, func() bool {
+		app.srvMu.Lock()
+		defer app.srvMu.Unlock()
+		return app.ln != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.ErrorContains(t, app.Restart(gocontext.Background()), "exited before being ready")
+
+	// The server keeps running
+	code, body := getBody(t, http.DefaultClient, "http://"+l.Addr().String()+"/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "OK", body)
+	assert.NoError(t, app.Shutdown(gocontext.Background()))
+}
+
+func TestHarmony_Restart_NotRunning(t *testing.T) {
+	assert.ErrorContains(t, New().Restart(gocontext.Background()), "not running")
+}
+
+// restartTestCommand makes Restart run only the test named by pattern in the new process.
+func restartTestCommand(t *testing.T, pattern string) {
+	cmd := restartCommand
+	restartCommand = func() (string, []string, error) {
+		return os.Args[0], []string{"-test.run=" + pattern}, nil
+	}
+	t.Cleanup(func() {
+		restartCommand = cmd
+	})
+}
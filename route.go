@@ -0,0 +1,236 @@
+package harmony
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+type (
+	// Route is a route registered on Harmony.
+	Route struct {
+		// Method is the HTTP method of the route.
+		Method string
+
+		// Path is the full path of the route, including the group prefixes.
+		Path string
+
+		harmony     *Harmony
+		name        string
+		handler     HandlerFunc
+		middlewares []MiddlewareFunc
+		timeout     time.Duration
+		compiled    atomic.Pointer[HandlerFunc]
+	}
+
+	// RouteInfo describes a route registered on Harmony.
+	RouteInfo struct {
+		// Method is the HTTP method of the route.
+		Method string `json:"method"`
+
+		// Path is the full path of the route, including the group prefixes.
+		Path string `json:"path"`
+
+		// Name is the name of the route, if any.
+		Name string `json:"name,omitempty"`
+
+		// Handler is the function name of the handler.
+		Handler string `json:"handler"`
+
+		// Middlewares are the function names of the middlewares running before
+		// the handler, global middlewares first.
+		Middlewares []string `json:"middlewares"`
+	}
+)
+
+// Name names the route so that its URL can be built with Harmony.URL.
+// It panics if another route already has the name.
+func (r *Route) Name(name string) *Route {
+	r.harmony.mu.Lock()
+	defer r.harmony.mu.Unlock()
+	if other, ok := r.harmony.names[name]; ok && other != r {
+		panic("harmony: route name '" + name + "' is already used by " + other.Method + " " + other.Path)
+	}
+	if r.name != "" {
+		delete(r.harmony.names, r.name)
+	}
+	r.name = name
+	r.harmony.names[name] = r
+	return r
+}
+
+// Timeout cancels the request context of the route after d. The handler is not
+// interrupted: it must watch Context().Done() to stop early. If it returns after
+// d without having written the response, ErrServiceUnavailable is sent to the
+// error handler, while a response written after d is still sent.
+func (r *Route) Timeout(d time.Duration) *Route {
+	r.harmony.mu.Lock()
+	defer r.harmony.mu.Unlock()
+	r.timeout = d
+	if r.harmony.frozen {
+		r.compile()
+	}
+	return r
+}
+
+// compile builds the handler chain of the route.
+func (r *Route) compile() {
+	handler := applyMiddleware(r.handler, r.middlewares...)
+	if r.timeout > 0 {
+		handler = timeoutHandler(handler, r.timeout)
+	}
+	r.compiled.Store(&handler)
+}
+
+// serve runs the compiled handler chain of the route.
+func (r *Route) serve(ctx Context) error {
+	return (*r.compiled.Load())(ctx)
+}
+
+// timeoutHandler runs next with a request context canceled after d.
+func timeoutHandler(next HandlerFunc, d time.Duration) HandlerFunc {
+	return func(ctx Context) error {
+		req := ctx.Request()
+		tctx, cancel := gocontext.WithTimeout(req.Context(), d)
+		defer cancel()
+
+		ctx.SetContext(tctx)
+		err := next(ctx)
+		ctx.setRequest(req)
+
+		if errors.Is(tctx.Err(), gocontext.DeadlineExceeded) && req.Context().Err() == nil && !ctx.isCommitted() {
+			return ErrServiceUnavailable
+		}
+		return err
+	}
+}
+
+// URL builds the path of the route named name, replacing its path parameters
+// in order with params. Params are escaped, and a wildcard param keeps its slashes.
+func (h *Harmony) URL(name string, params ...any) (string, error) {
+	h.mu.RLock()
+	r, ok := h.names[name]
+	h.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("harmony: route '%s' not found", name)
+	}
+	return r.url(params...)
+}
+
+func (r *Route) url(params ...any) (string, error) {
+	segments := strings.Split(r.Path, "/")
+	n := 0
+	for i, s := range segments {
+		name, wildcard, ok := paramSegment(s, i == len(segments)-1)
+		if !ok {
+			continue
+		}
+		if n >= len(params) {
+			return "", fmt.Errorf("harmony: missing param '%s' for route '%s'", name, r.name)
+		}
+
+		v := fmt.Sprint(params[n])
+		n++
+		if !wildcard {
+			segments[i] = url.PathEscape(v)
+			continue
+		}
+		parts := strings.Split(v, "/")
+		for j, p := range parts {
+			parts[j] = url.PathEscape(p)
+		}
+		segments[i] = strings.Join(parts, "/")
+	}
+	if n < len(params) {
+		return "", errors.New("harmony: too many params for route '" + r.name + "'")
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// paramSegment returns the name of the param declared by the path segment s,
+// either as :name, *name or gorilla's {name}, and whether it is a wildcard.
+func paramSegment(s string, last bool) (name string, wildcard, ok bool) {
+	switch {
+	case strings.HasPrefix(s, ":"):
+		return s[1:], false, true
+	case strings.HasPrefix(s, "*") && last:
+		if s == "*" {
+			return s, true, true
+		}
+		return s[1:], true, true
+	case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+		name, pattern, _ := strings.Cut(s[1:len(s)-1], ":")
+		return name, pattern == ".*", true
+	}
+	return "", false, false
+}
+
+// Routes returns the routes registered on Harmony in registration order.
+func (h *Harmony) Routes() []RouteInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.routeInfos(h.routes)
+}
+
+// PrintRoutes writes the route table sorted by path and method to w.
+func (h *Harmony) PrintRoutes(w io.Writer) {
+	routes := h.Routes()
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tHANDLER\tMIDDLEWARES")
+	for _, r := range routes {
+		name, middlewares := r.Name, strings.Join(r.Middlewares, ", ")
+		if name == "" {
+			name = "-"
+		}
+		if middlewares == "" {
+			middlewares = "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Path, name, r.Handler, middlewares)
+	}
+	_ = tw.Flush()
+}
+
+func (h *Harmony) routeInfos(routes []*Route) []RouteInfo {
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, r := range routes {
+		middlewares := make([]string, 0, len(h.middlewares)+len(r.middlewares))
+		for _, m := range h.middlewares {
+			middlewares = append(middlewares, funcName(m))
+		}
+		for _, m := range r.middlewares {
+			middlewares = append(middlewares, funcName(m))
+		}
+		infos = append(infos, RouteInfo{
+			Method:      r.Method,
+			Path:        r.Path,
+			Name:        r.name,
+			Handler:     funcName(r.handler),
+			Middlewares: middlewares,
+		})
+	}
+	return infos
+}
+
+// funcName returns the fully qualified name of the function fn.
+func funcName(fn any) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package harmony
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHarmony_URL(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", writeStringOKHandler()).Name("user.show")
+	app.Get("/files/*path", writeStringOKHandler()).Name("file.show")
+	app.Get("/posts/{id:[0-9]+}", writeStringOKHandler()).Name("post.show")
+	api := app.Group("/api").Group("/v1")
+	api.Get("/users/:id/posts/:post_id", writeStringOKHandler()).Name("api.user.post")
+
+	tests := []struct {
+		name   string
+		params []any
+		url    string
+	}{
+		{name: "user.show", params: []any{1}, url: "/users/1"},
+		{name: "user.show", params: []any{"john doe/1"}, url: "/users/john%20doe%2F1"},
+		{name: "file.show", params: []any{"css/app file.css"}, url: "/files/css/app%20file.css"},
+		{name: "post.show", params: []any{2}, url: "/posts/2"},
+		{name: "api.user.post", params: []any{1, 2}, url: "/api/v1/users/1/posts/2"},
+	}
+	for _, tt := range tests {
+		url, err := app.URL(tt.name, tt.params...)
+		if assert.NoError(t, err) {
+			assert.Equal(t, tt.url, url)
+		}
+	}
+
+	_, err := app.URL("api.user.post", 1)
+	assert.EqualError(t, err, "harmony: missing param 'post_id' for route 'api.user.post'")
+	_, err = app.URL("user.show", 1, 2)
+	assert.EqualError(t, err, "harmony: too many params for route 'user.show'")
+	_, err = app.URL("user.delete")
+	assert.EqualError(t, err, "harmony: route 'user.delete' not found")
+}
+
+func TestRoute_Name(t *testing.T) {
+	app := New()
+	r := app.Get("/users", writeStringOKHandler()).Name("users")
+	assert.NotPanics(t, func() { r.Name("users") })
+	assert.Panics(t, func() { app.Post("/users", writeStringOKHandler()).Name("users") })
+
+	r.Name("user.list")
+	_, err := app.URL("users")
+	assert.Error(t, err)
+	url, err := app.URL("user.list")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users", url)
+}
+
+func TestContext_URL(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", writeStringOKHandler()).Name("user.show")
+	app.Get("/", func(ctx Context) error {
+		url, err := ctx.URL("user.show", 1)
+		if err != nil {
+			return err
+		}
+		return ctx.String(http.StatusOK, url)
+	})
+
+	recCode, recBody := newRequest(http.MethodGet, "/", app)
+	assert.Equal(t, http.StatusOK, recCode)
+	assert.Equal(t, "/users/1", recBody)
+}
+
+func TestHarmony_Routes(t *testing.T) {
+	app := New()
+	app.Use(testMiddleware)
+	app.Get("/users/:id", testHandler).Name("user.show")
+	api := app.Group("/api", testMiddleware)
+	api.Group("/v1").Post("/users", testHandler)
+	app.Delete("/users/:id", testHandler, testMiddleware)
+
+	assert.Equal(t, []RouteInfo{
+		{
+			Method:      http.MethodGet,
+			Path:        "/users/:id",
+			Name:        "user.show",
+			Handler:     "github.com/SyntaxCrew/harmony.testHandler",
+			Middlewares: []string{"github.com/SyntaxCrew/harmony.testMiddleware"},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/api/v1/users",
+			Handler:     "github.com/SyntaxCrew/harmony.testHandler",
+			Middlewares: []string{"github.com/SyntaxCrew/harmony.testMiddleware", "github.com/SyntaxCrew/harmony.testMiddleware"},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/users/:id",
+			Handler:     "github.com/SyntaxCrew/harmony.testHandler",
+			Middlewares: []string{"github.com/SyntaxCrew/harmony.testMiddleware", "github.com/SyntaxCrew/harmony.testMiddleware"},
+		},
+	}, app.Routes())
+
+	routes := api.Routes()
+	if assert.Len(t, routes, 1) {
+		assert.Equal(t, "/api/v1/users", routes[0].Path)
+	}
+}
+
+func TestHarmony_PrintRoutes(t *testing.T) {
+	app := New()
+	app.Post("/users", testHandler)
+	app.Get("/users/:id", testHandler).Name("user.show")
+	app.Get("/users", testHandler)
+
+	buf := bytes.NewBuffer([]byte{})
+	app.PrintRoutes(buf)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 4) {
+		assert.Regexp(t, `^METHOD\s+PATH\s+NAME\s+HANDLER\s+MIDDLEWARES$`, lines[0])
+		assert.Regexp(t, `^GET\s+/users\s+-\s+github.com/SyntaxCrew/harmony.testHandler\s+-$`, lines[1])
+		assert.Regexp(t, `^POST\s+/users\s+-\s+github.com/SyntaxCrew/harmony.testHandler\s+-$`, lines[2])
+		assert.Regexp(t, `^GET\s+/users/:id\s+user.show\s+github.com/SyntaxCrew/harmony.testHandler\s+-$`, lines[3])
+	}
+}
+
+func testHandler(ctx Context) error {
+	return ctx.SendStatus(http.StatusOK)
+}
+
+func testMiddleware(next HandlerFunc) HandlerFunc {
+	return next
+}
+
+func TestRoute_Timeout(t *testing.T) {
+	app := New()
+	var outerErr error
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx Context) error {
+			err := next(ctx)
+			outerErr = ctx.Context().Err()
+			return err
+		}
+	})
+	app.Get("/slow", func(ctx Context) error {
+		<-ctx.Context().Done()
+		return ctx.Context().Err()
+	}).Timeout(10 * time.Millisecond)
+	app.Get("/fast", func(ctx Context) error {
+		_, ok := ctx.Context().Deadline()
+		assert.True(t, ok)
+		return ctx.String(http.StatusOK, "OK")
+	}).Timeout(time.Second)
+	app.Get("/written", func(ctx Context) error {
+		_ = ctx.String(http.StatusAccepted, "accepted")
+		<-ctx.Context().Done()
+		return nil
+	}).Timeout(10 * time.Millisecond)
+
+	code, body := newRequest(http.MethodGet, "/slow", app)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.JSONEq(t, `{"message":"Service Unavailable"}`, body)
+	assert.NoError(t, outerErr)
+
+	code, body = newRequest(http.MethodGet, "/fast", app)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "OK", body)
+
+	code, body = newRequest(http.MethodGet, "/written", app)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "accepted", body)
+
+	// The timeout is cooperative, a response written late is still sent
+	app.Get("/late-write", func(ctx Context) error {
+		time.Sleep(30 * time.Millisecond)
+		return ctx.String(http.StatusOK, "late")
+	}).Timeout(10 * time.Millisecond)
+	code, body = newRequest(http.MethodGet, "/late-write", app)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "late", body)
+
+	// Routes are recompiled after the first request
+	route := app.Get("/late", func(ctx Context) error {
+		<-ctx.Context().Done()
+		return nil
+	})
+	route.Timeout(10 * time.Millisecond)
+	code, _ = newRequest(http.MethodGet, "/late", app)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
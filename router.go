@@ -0,0 +1,38 @@
+package harmony
+
+type (
+	// Router is the interface implemented by the routers used by Harmony.
+	//
+	// Paths are made of static segments, :param segments matching a single
+	// path segment and a trailing *wildcard segment matching the rest of the path.
+	Router interface {
+		// Add registers the handler for the method and path.
+		Add(method, path string, handler HandlerFunc)
+
+		// Find returns the handler registered for the method and path and appends
+		// the matched path parameters to params. It returns nil if no route matches.
+		Find(method, path string, params *Params) HandlerFunc
+
+		// Methods returns the sorted methods registered for the path.
+		Methods(path string) []string
+	}
+
+	// Param is a path parameter matched by a Router.
+	Param struct {
+		Key   string
+		Value string
+	}
+
+	// Params is the list of path parameters matched by a Router.
+	Params []Param
+)
+
+// Get returns the value of the path parameter by key.
+func (ps Params) Get(key string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,185 @@
+package harmony
+
+import (
+	gocontext "context"
+	"crypto/tls"
+	"errors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+)
+
+// ListenAndServe starts the server on Config.Addr, or on its host and port if given.
+// When the process is started by systemd socket activation, the server accepts
+// on the passed socket instead.
+func (h *Harmony) ListenAndServe(port ...int) error {
+	l, err := h.listen(port...)
+	if err != nil {
+		return err
+	}
+	return h.Serve(l)
+}
+
+// Serve accepts incoming connections on the listener l.
+func (h *Harmony) Serve(l net.Listener) error {
+	return h.serve(l, nil)
+}
+
+// OnStart registers hooks run in order before the server accepts connections.
+// The server does not start if a hook returns an error.
+func (h *Harmony) OnStart(hooks ...func() error) {
+	h.startHooks = append(h.startHooks, hooks...)
+}
+
+// OnShutdown registers hooks run in order once the server has shut down,
+// such as closing database pools or flushing telemetry.
+func (h *Harmony) OnShutdown(hooks ...func(ctx gocontext.Context) error) {
+	h.shutdownHooks = append(h.shutdownHooks, hooks...)
+}
+
+// Shutdown gracefully shuts down the server, waiting for active connections
+// to finish until ctx is done, then runs the OnShutdown hooks. The hooks run
+// only on the first call. A server started after Shutdown returns http.ErrServerClosed.
+func (h *Harmony) Shutdown(ctx gocontext.Context) error {
+	h.srvMu.Lock()
+	srv, closed := h.srv, h.closed
+	h.closed = true
+	h.srvMu.Unlock()
+
+	var errs []error
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !closed {
+		for _, hook := range h.shutdownHooks {
+			if err := hook(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GracefulShutdown waits for one of Config.ShutdownSignals, then shuts down
+// the server within Config.ShutdownTimeout. On one of Config.RestartSignals,
+// it hands the listener over to a new process, as Restart does, before shutting
+// down; the server keeps running if the new process fails to start.
+func (h *Harmony) GracefulShutdown() error {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, slices.Concat(h.config.ShutdownSignals, h.config.RestartSignals)...)
+	defer signal.Stop(c)
+
+	for sig := range c {
+		ctx, cancel := h.shutdownContext()
+		if slices.Contains(h.config.RestartSignals, sig) {
+			if err := h.handoff(ctx); err != nil {
+				cancel()
+				log.Printf("harmony: restart failed: %v", err)
+				continue
+			}
+		}
+
+		err := h.Shutdown(ctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+		break
+	}
+
+	log.Println("harmony: gracefully shutdown")
+	return nil
+}
+
+// shutdownContext returns the context of GracefulShutdown, canceled after Config.ShutdownTimeout.
+func (h *Harmony) shutdownContext() (gocontext.Context, gocontext.CancelFunc) {
+	if h.config.ShutdownTimeout > 0 {
+		return gocontext.WithTimeout(gocontext.Background(), h.config.ShutdownTimeout)
+	}
+	return gocontext.WithCancel(gocontext.Background())
+}
+
+// serve runs the OnStart hooks and accepts incoming connections on l, with
+// TLS if tlsConfig is not nil.
+func (h *Harmony) serve(l net.Listener, tlsConfig *tls.Config) error {
+	for _, hook := range h.startHooks {
+		if err := hook(); err != nil {
+			_ = l.Close()
+			return err
+		}
+	}
+
+	addr := l.Addr().String()
+	srv := h.newServer(addr)
+	srv.TLSConfig = tlsConfig
+
+	h.srvMu.Lock()
+	if h.closed {
+		h.srvMu.Unlock()
+		_ = l.Close()
+		return http.ErrServerClosed
+	}
+	h.srv, h.ln = srv, l
+	h.srvMu.Unlock()
+
+	h.printBanner(addr)
+	notifyReady()
+	if h.config.ProxyProtocol {
+		l = &proxyListener{Listener: l, timeout: h.proxyHeaderTimeout()}
+	}
+	if tlsConfig != nil {
+		return srv.ServeTLS(l, "", "")
+	}
+	return srv.Serve(l)
+}
+
+// proxyHeaderTimeout returns the maximum duration for reading the PROXY protocol header.
+func (h *Harmony) proxyHeaderTimeout() time.Duration {
+	if h.config.ReadHeaderTimeout != 0 {
+		return h.config.ReadHeaderTimeout
+	}
+	return h.config.ReadTimeout
+}
+
+// newServer returns the http.Server serving Harmony on addr with the server options of Config.
+func (h *Harmony) newServer(addr string) *http.Server {
+	h.freezeOnce.Do(h.freeze)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadTimeout:       h.config.ReadTimeout,
+		ReadHeaderTimeout: h.config.ReadHeaderTimeout,
+		WriteTimeout:      h.config.WriteTimeout,
+		IdleTimeout:       h.config.IdleTimeout,
+		MaxHeaderBytes:    h.config.MaxHeaderBytes,
+		ErrorLog:          h.config.ErrorLog,
+		BaseContext:       h.config.BaseContext,
+		ConnContext:       h.config.ConnContext,
+	}
+	if h.config.H2C {
+		// ConfigureServer lets Shutdown send GOAWAY to h2c connections too
+		h2s := &http2.Server{}
+		_ = http2.ConfigureServer(srv, h2s)
+		srv.Handler = h2c.NewHandler(h, h2s)
+	}
+	return srv
+}
+
+func (h *Harmony) printBanner(addr string) {
+	if !h.config.HideBanner {
+		log.Print(banner)
+	}
+	log.Printf("harmony: server is listening and serving at %s", addr)
+	if h.config.ShowRoutes {
+		h.PrintRoutes(log.Writer())
+	}
+}
@@ -0,0 +1,261 @@
+package harmony
+
+import (
+	"bufio"
+	"bytes"
+	gocontext "context"
+	"crypto/tls"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHarmony_NewServer(t *testing.T) {
+	app := New()
+	srv := app.newServer(app.config.Addr)
+	assert.Equal(t, "0.0.0.0:8080", srv.Addr)
+	assert.Equal(t, 60*time.Second, srv.ReadTimeout)
+	assert.Equal(t, time.Duration(0), srv.ReadHeaderTimeout)
+	assert.Equal(t, 60*time.Second, srv.WriteTimeout)
+	assert.Equal(t, 60*time.Second, srv.IdleTimeout)
+	assert.Same(t, app, srv.Handler)
+	assert.Equal(t, []os.Signal{os.Interrupt, syscall.SIGTERM}, app.config.ShutdownSignals)
+	assert.Equal(t, 10*time.Second, app.config.ShutdownTimeout)
+
+	errorLog := log.New(os.Stderr, "", 0)
+	baseContext := func(net.Listener) gocontext.Context { return gocontext.Background() }
+	app = New(&Config{
+		Addr:              "127.0.0.1:9090",
+		ReadTimeout:       5 * time.Second,
+		ReadHeaderTimeout: time.Second,
+		WriteTimeout:      -1,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    1 << 10,
+		ErrorLog:          errorLog,
+		BaseContext:       baseContext,
+	})
+	srv = app.newServer(app.config.Addr)
+	assert.Equal(t, "127.0.0.1:9090", srv.Addr)
+	assert.Equal(t, 5*time.Second, srv.ReadTimeout)
+	assert.Equal(t, time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, time.Duration(-1), srv.WriteTimeout)
+	assert.Equal(t, 2*time.Minute, srv.IdleTimeout)
+	assert.Equal(t, 1<<10, srv.MaxHeaderBytes)
+	assert.Same(t, errorLog, srv.ErrorLog)
+	assert.NotNil(t, srv.BaseContext)
+}
+
+func TestHarmony_ListenAndServe(t *testing.T) {
+	app := New(&Config{Addr: "invalid", HideBanner: true})
+	assert.Error(t, app.ListenAndServe(8080))
+}
+
+func TestHarmony_Shutdown(t *testing.T) {
+	var calls []string
+	started, release := make(chan struct{}), make(chan struct{})
+	app := New(&Config{HideBanner: true})
+	app.Get("/", func(ctx Context) error {
+		close(started)
+		<-release
+		calls = append(calls, "handler")
+		return ctx.String(http.StatusOK, "OK")
+	})
+	app.OnStart(func() error {
+		calls = append(calls, "start")
+		return nil
+	})
+	app.OnShutdown(func(gocontext.Context) error {
+		calls = append(calls, "db")
+		return nil
+	}, func(gocontext.Context) error {
+		calls = append(calls, "telemetry")
+		return nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- app.Serve(l)
+	}()
+
+	type result struct {
+		code int
+		body string
+	}
+	responses := make(chan result, 1)
+	go func() {
+		code, body := getBody(t, http.DefaultClient, "http://"+l.Addr().String()+"/")
+		responses <- result{code, body}
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- app.Shutdown(gocontext.Background())
+	}()
+	assert.ErrorIs(t, <-served, http.ErrServerClosed)
+
+	// The active request is drained before the hooks run
+	close(release)
+	assert.NoError(t, <-shutdown)
+	assert.Equal(t, result{http.StatusOK, "OK"}, <-responses)
+	assert.Equal(t, []string{"start", "handler", "db", "telemetry"}, calls)
+
+	// Hooks run only once
+	assert.NoError(t, app.Shutdown(gocontext.Background()))
+	assert.Equal(t, []string{"start", "handler", "db", "telemetry"}, calls)
+}
+
+func TestHarmony_Shutdown_NotStarted(t *testing.T) {
+	hookErr := errors.New("flush failed")
+	app := New(&Config{HideBanner: true})
+	app.OnShutdown(func(gocontext.Context) error { return hookErr })
+
+	assert.ErrorIs(t, app.Shutdown(gocontext.Background()), hookErr)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	assert.ErrorIs(t, app.Serve(l), http.ErrServerClosed)
+}
+
+func TestHarmony_OnStart(t *testing.T) {
+	hookErr := errors.New("database unreachable")
+	var calls []string
+	app := New(&Config{HideBanner: true})
+	app.OnStart(func() error {
+		calls = append(calls, "first")
+		return hookErr
+	}, func() error {
+		calls = append(calls, "second")
+		return nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	assert.ErrorIs(t, app.Serve(l), hookErr)
+	assert.Equal(t, []string{"first"}, calls)
+
+	// The listener is closed
+	_, err = net.Dial("tcp", l.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestHarmony_GracefulShutdown(t *testing.T) {
+	// Keep SIGHUP from terminating the test binary
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	defer signal.Stop(c)
+
+	var hooked bool
+	app := New(&Config{HideBanner: true, ShutdownSignals: []os.Signal{syscall.SIGHUP}})
+	app.OnShutdown(func(ctx gocontext.Context) error {
+		_, ok := ctx.Deadline()
+		hooked = ok
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- app.GracefulShutdown()
+	}()
+
+	p, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	for {
+		require.NoError(t, p.Signal(syscall.SIGHUP))
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+			assert.True(t, hooked)
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestHarmony_H2C(t *testing.T) {
+	app := New(&Config{H2C: true, HideBanner: true})
+	app.Get("/", func(ctx Context) error {
+		return ctx.String(http.StatusOK, ctx.Protocol())
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = app.Serve(l)
+	}()
+	defer app.Shutdown(gocontext.Background())
+	url := "http://" + l.Addr().String() + "/"
+
+	// Prior knowledge
+	tr := &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx gocontext.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+	defer tr.CloseIdleConnections()
+	code, body := getBody(t, &http.Client{Transport: tr}, url)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "HTTP/2.0", body)
+
+	code, body = getBody(t, http.DefaultClient, url)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "HTTP/1.1", body)
+
+	// Upgrade: the request is answered on stream 1 after switching protocols
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: \r\n\r\n")
+	require.NoError(t, err)
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, "h2c", res.Header.Get("Upgrade"))
+
+	_, err = io.WriteString(conn, http2.ClientPreface)
+	require.NoError(t, err)
+	framer := http2.NewFramer(conn, br)
+	require.NoError(t, framer.WriteSettings())
+	readData := func(streamID uint32) string {
+		var data []byte
+		for {
+			f, err := framer.ReadFrame()
+			require.NoError(t, err)
+			if df, ok := f.(*http2.DataFrame); ok && df.StreamID == streamID {
+				data = append(data, df.Data()...)
+				if df.StreamEnded() {
+					return string(data)
+				}
+			}
+		}
+	}
+	// The upgraded request itself was sent in HTTP/1.1
+	assert.Equal(t, "HTTP/1.1", readData(1))
+
+	var block bytes.Buffer
+	enc := hpack.NewEncoder(&block)
+	for _, f := range [][2]string{{":method", "GET"}, {":scheme", "http"}, {":authority", "localhost"}, {":path", "/"}} {
+		require.NoError(t, enc.WriteField(hpack.HeaderField{Name: f[0], Value: f[1]}))
+	}
+	require.NoError(t, framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: 3, BlockFragment: block.Bytes(), EndStream: true, EndHeaders: true,
+	}))
+	assert.Equal(t, "HTTP/2.0", readData(3))
+}
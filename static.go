@@ -0,0 +1,305 @@
+package harmony
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultIndex = "index.html"
+)
+
+type (
+	// StaticConfig defines the config for Static and StaticFS.
+	StaticConfig struct {
+		// Index is the file served for a directory.
+		// Optional. Default value "index.html".
+		Index string
+
+		// Browse lists the content of a directory without index file.
+		// Optional. Default value false.
+		Browse bool
+
+		// MaxAge sets the max-age of the Cache-Control header.
+		// Optional. Default value 0, which sends no Cache-Control header.
+		MaxAge time.Duration
+
+		// SPA serves the index file of the root for paths matching no file,
+		// so that a single-page app can handle its own routes.
+		// Optional. Default value false.
+		SPA bool
+	}
+
+	// contentETagKey identifies a file of contentETags.
+	contentETagKey struct {
+		fsys fs.FS
+		name string
+		size int64
+	}
+)
+
+// contentETags caches the ETags built from the content of the files without
+// modification time, which do not change, as in an embed.FS.
+var contentETags sync.Map
+
+// Static serves the files of the root directory under prefix.
+func (h *Harmony) Static(prefix, root string, staticCfg ...*StaticConfig) []*Route {
+	return h.StaticFS(prefix, os.DirFS(root), staticCfg...)
+}
+
+// StaticFS serves the files of fsys under prefix.
+func (h *Harmony) StaticFS(prefix string, fsys fs.FS, staticCfg ...*StaticConfig) []*Route {
+	return static(h.Get, prefix, fsys, staticCfg)
+}
+
+// Static serves the files of the root directory under the group prefix and prefix.
+func (g *Group) Static(prefix, root string, staticCfg ...*StaticConfig) []*Route {
+	return g.StaticFS(prefix, os.DirFS(root), staticCfg...)
+}
+
+// StaticFS serves the files of fsys under the group prefix and prefix.
+func (g *Group) StaticFS(prefix string, fsys fs.FS, staticCfg ...*StaticConfig) []*Route {
+	return static(g.Get, prefix, fsys, staticCfg)
+}
+
+// static registers the static handler with get for prefix and every path under it.
+func static(
+	get func(path string, handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) *Route,
+	prefix string,
+	fsys fs.FS,
+	staticCfg []*StaticConfig,
+) []*Route {
+	var cfg StaticConfig
+	if len(staticCfg) > 0 && staticCfg[0] != nil {
+		cfg = *staticCfg[0]
+	}
+	if cfg.Index == "" {
+		cfg.Index = defaultIndex
+	}
+
+	handler := staticHandler(fsys, cfg)
+	prefix = strings.TrimSuffix(prefix, "/")
+	routes := []*Route{get(prefix+"/*", handler)}
+	if prefix != "" {
+		routes = append(routes, get(prefix, handler))
+	}
+	return routes
+}
+
+func staticHandler(fsys fs.FS, cfg StaticConfig) HandlerFunc {
+	return func(ctx Context) error {
+		name := cleanFSPath(ctx.PathParam("*"))
+		notFound := func() error {
+			if cfg.SPA {
+				return serveStaticFile(ctx, fsys, cfg.Index, cfg)
+			}
+			return ErrNotFound
+		}
+
+		fi, err := fs.Stat(fsys, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return notFound()
+			}
+			return err
+		}
+
+		if !fi.IsDir() {
+			return serveStaticFile(ctx, fsys, name, cfg)
+		}
+
+		r := ctx.Request()
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			// The target is relative to the directory, as in http.FileServer,
+			// so that a path such as //assets is not read as another host.
+			u := url.URL{Path: path.Base(r.URL.Path) + "/", RawQuery: r.URL.RawQuery}
+			return ctx.Redirect(http.StatusMovedPermanently, u.String())
+		}
+
+		index := path.Join(name, cfg.Index)
+		if _, err := fs.Stat(fsys, index); err == nil {
+			return serveStaticFile(ctx, fsys, index, cfg)
+		}
+		if cfg.Browse {
+			return listDirectory(ctx, fsys, name)
+		}
+		return notFound()
+	}
+}
+
+func serveStaticFile(ctx Context, fsys fs.FS, name string, cfg StaticConfig) error {
+	if cfg.MaxAge > 0 {
+		ctx.ResponseWriter().Header().Set(HeaderCacheControl, "public, max-age="+strconv.Itoa(int(cfg.MaxAge.Seconds())))
+	}
+	return serveFS(ctx, fsys, name)
+}
+
+// serveFS serves the file name of fsys with http.ServeContent, which handles
+// Range requests and conditional GET. The ETag of fileETag is set unless the
+// handler has set one.
+func serveFS(ctx Context, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return ErrNotFound
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(b)
+	}
+
+	if h := ctx.ResponseWriter().Header(); h.Get(HeaderETag) == "" {
+		etag, err := fileETag(fsys, name, fi, content)
+		if err != nil {
+			return err
+		}
+		h.Set(HeaderETag, etag)
+	}
+	http.ServeContent(ctx.ResponseWriter(), ctx.Request(), fi.Name(), fi.ModTime(), content)
+	return nil
+}
+
+// fileETag returns a weak ETag built from the size and modification time of
+// the file, or a strong ETag built from the SHA-256 hash of its content when
+// the modification time is unknown, as for the files of an embed.FS. The hash
+// is computed once per file of a comparable fsys, such as an embed.FS.
+func fileETag(fsys fs.FS, name string, fi fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !fi.ModTime().IsZero() {
+		return fmt.Sprintf(`W/"%x-%x"`, fi.Size(), fi.ModTime().UnixNano()), nil
+	}
+
+	cacheable := reflect.ValueOf(fsys).Comparable()
+	key := contentETagKey{name: name, size: fi.Size()}
+	if cacheable {
+		key.fsys = fsys
+		if etag, ok := contentETags.Load(key); ok {
+			return etag.(string), nil
+		}
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := fmt.Sprintf(`"%x"`, hash.Sum(nil))
+	if cacheable {
+		contentETags.Store(key, etag)
+	}
+	return etag, nil
+}
+
+// contentDisposition returns the Content-Disposition header of RFC 6266 for the
+// disposition type and the file name. A name that is not plain ASCII is sent
+// as an RFC 5987 filename* parameter, with an ASCII fallback for old clients.
+func contentDisposition(typ, name string) string {
+	if name == "" {
+		return typ
+	}
+
+	var fallback strings.Builder
+	ascii := true
+	for _, r := range name {
+		switch {
+		case r >= utf8.RuneSelf || r < ' ' || r == 0x7f:
+			ascii = false
+			fallback.WriteByte('_')
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+	disposition := typ + `; filename="` + fallback.String() + `"`
+	if ascii {
+		return disposition
+	}
+
+	var encoded strings.Builder
+	for _, b := range []byte(name) {
+		if isAttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			_, _ = fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return disposition + "; filename*=UTF-8''" + encoded.String()
+}
+
+// isAttrChar reports whether b is an attr-char of RFC 5987, which needs no
+// percent-encoding in an extended parameter value.
+func isAttrChar(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
+
+func listDirectory(ctx Context, fsys fs.FS, name string) error {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() {
+			n += "/"
+		}
+		u := url.URL{Path: n}
+		_, _ = fmt.Fprintf(&buf, "<a href=\"%s\">%s</a>\n", u.String(), html.EscapeString(n))
+	}
+	buf.WriteString("</pre>\n")
+
+	ctx.ResponseWriter().Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8)
+	ctx.ResponseWriter().WriteHeader(http.StatusOK)
+	_, err = buf.WriteTo(ctx.ResponseWriter())
+	return err
+}
+
+// cleanFSPath returns the fs.FS name of the request path p, which cannot
+// escape the root of the file system.
+func cleanFSPath(p string) string {
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
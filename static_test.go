@@ -0,0 +1,163 @@
+package harmony
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestHarmony_Static(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "index.html"), []byte("<h1>Harmony</h1>"), 0o600))
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "css"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "css", "app.css"), []byte("body{}"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(root), "secret.txt"), []byte("secret"), 0o600))
+
+	app := New()
+	app.Static("/assets", root, &StaticConfig{MaxAge: time.Hour})
+
+	rec := serveStatic(app, "/assets/css/app.css")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "body{}", rec.Body.String())
+	assert.Equal(t, "text/css; charset=utf-8", rec.Header().Get(HeaderContentType))
+	assert.Equal(t, "public, max-age=3600", rec.Header().Get(HeaderCacheControl))
+
+	rec = serveStatic(app, "/assets/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<h1>Harmony</h1>", rec.Body.String())
+
+	rec = serveStatic(app, "/assets")
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "assets/", rec.Header().Get(HeaderLocation))
+	rec = serveStatic(app, "/assets/css?v=1")
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "css/?v=1", rec.Header().Get(HeaderLocation))
+
+	rec = serveStatic(app, "/assets/../secret.txt")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = serveStatic(app, "/assets/%2e%2e/secret.txt")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveStatic(app, "/assets/css/")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHarmony_StaticRedirect(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "assets"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "assets", "index.html"), []byte("assets"), 0o600))
+
+	app := New()
+	app.Static("/", root)
+
+	// A leading // must not redirect to the host assets
+	for _, target := range []string{"//assets", "///assets", "/assets"} {
+		rec := serveStatic(app, target)
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code, target)
+		assert.Equal(t, "assets/", rec.Header().Get(HeaderLocation), target)
+	}
+}
+
+func TestHarmony_StaticFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":  {Data: []byte("<h1>SPA</h1>")},
+		"js/app.js":   {Data: []byte("console.log(1)")},
+		"docs/a.txt":  {Data: []byte("a")},
+		"docs/b c.md": {Data: []byte("b")},
+	}
+
+	app := New()
+	app.Group("/web").StaticFS("/", fsys, &StaticConfig{SPA: true})
+	app.StaticFS("/files", fsys, &StaticConfig{Browse: true})
+
+	rec := serveStatic(app, "/web/js/app.js")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "console.log(1)", rec.Body.String())
+
+	rec = serveStatic(app, "/web/users/1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<h1>SPA</h1>", rec.Body.String())
+
+	rec = serveStatic(app, "/files/docs/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Contains(t, rec.Body.String(), `<a href="a.txt">a.txt</a>`)
+	assert.Contains(t, rec.Body.String(), `<a href="b%20c.md">b c.md</a>`)
+
+	rec = serveStatic(app, "/files/missing.txt")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHarmony_StaticFSContentETag(t *testing.T) {
+	fsys := &readCountFS{FS: fstest.MapFS{"app.js": {Data: []byte("console.log(1)")}}}
+	app := New()
+	app.StaticFS("/", fsys)
+
+	serve := func(method string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/app.js", nil)
+		req.Header = header
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodHead, http.Header{})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get(HeaderETag)
+	assert.NotEmpty(t, etag)
+	assert.Positive(t, fsys.reads)
+
+	// The hash of the content is computed once
+	fsys.reads = 0
+	rec = serve(http.MethodHead, http.Header{})
+	assert.Equal(t, etag, rec.Header().Get(HeaderETag))
+	rec = serve(http.MethodGet, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Zero(t, fsys.reads)
+
+	rec = serve(http.MethodGet, http.Header{})
+	assert.Equal(t, "console.log(1)", rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get(HeaderETag))
+}
+
+// readCountFS counts the reads of the files of FS.
+type readCountFS struct {
+	fs.FS
+	reads int
+}
+
+func (f *readCountFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &readCountFile{File: file, fsys: f}, nil
+}
+
+type readCountFile struct {
+	fs.File
+	fsys *readCountFS
+}
+
+func (f *readCountFile) Read(b []byte) (int, error) {
+	f.fsys.reads++
+	return f.File.Read(b)
+}
+
+func (f *readCountFile) Seek(offset int64, whence int) (int64, error) {
+	return f.File.(io.Seeker).Seek(offset, whence)
+}
+
+func serveStatic(app *Harmony, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	return rec
+}
@@ -0,0 +1,61 @@
+package harmony
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type (
+	// Key is a typed key of the Context store. Keys are compared by identity,
+	// so that two packages using the same name cannot collide.
+	Key[T any] struct {
+		name string
+	}
+)
+
+// NewKey returns a new Key for values of type T. The name is only used in
+// error messages.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// Get returns the value of the key in ctx, and whether it is set.
+func (k *Key[T]) Get(ctx Context) (T, bool) {
+	return GetAs[T](ctx, k)
+}
+
+// MustGet returns the value of the key in ctx. It panics if the key is not set.
+func (k *Key[T]) MustGet(ctx Context) T {
+	return MustGet[T](ctx, k)
+}
+
+// Set sets the value of the key in ctx.
+func (k *Key[T]) Set(ctx Context, value T) {
+	ctx.Set(k, value)
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// GetAs returns the value in ctx by key as a T, and whether it is set with
+// this type.
+func GetAs[T any](ctx Context, key any) (T, bool) {
+	v, ok := ctx.Get(key).(T)
+	return v, ok
+}
+
+// MustGet returns the value in ctx by key as a T. It panics if the key is not
+// set or its value is not a T.
+func MustGet[T any](ctx Context, key any) T {
+	v := ctx.Get(key)
+	t, ok := v.(T)
+	if !ok {
+		if v == nil {
+			panic(fmt.Sprintf("harmony: key '%v' is not set", key))
+		}
+		panic(fmt.Sprintf("harmony: key '%v' has type %T, not %v", key, v, reflect.TypeFor[T]()))
+	}
+	return t
+}
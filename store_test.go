@@ -0,0 +1,81 @@
+package harmony
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestGetAs(t *testing.T) {
+	ctx := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Set("userID", 1)
+
+	id, ok := GetAs[int](ctx, "userID")
+	assert.True(t, ok)
+	assert.Equal(t, 1, id)
+
+	name, ok := GetAs[string](ctx, "userID")
+	assert.False(t, ok)
+	assert.Empty(t, name)
+
+	_, ok = GetAs[int](ctx, "missing")
+	assert.False(t, ok)
+}
+
+func TestMustGet(t *testing.T) {
+	ctx := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Set("userID", 1)
+
+	assert.Equal(t, 1, MustGet[int](ctx, "userID"))
+	assert.PanicsWithValue(t, "harmony: key 'userID' has type int, not string", func() {
+		MustGet[string](ctx, "userID")
+	})
+	assert.PanicsWithValue(t, "harmony: key 'missing' is not set", func() {
+		MustGet[int](ctx, "missing")
+	})
+}
+
+func TestKey(t *testing.T) {
+	type user struct{ Name string }
+	ctx := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Keys with the same name do not collide
+	userKey := NewKey[*user]("user")
+	otherKey := NewKey[string]("user")
+	userKey.Set(ctx, &user{Name: "John"})
+	otherKey.Set(ctx, "other")
+	ctx.Set("user", "string key")
+
+	u, ok := userKey.Get(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "John", u.Name)
+	assert.Equal(t, "John", userKey.MustGet(ctx).Name)
+	assert.Equal(t, "other", otherKey.MustGet(ctx))
+	assert.Equal(t, "string key", ctx.Get("user"))
+
+	missing := NewKey[int]("missing")
+	_, ok = missing.Get(ctx)
+	assert.False(t, ok)
+	assert.PanicsWithValue(t, "harmony: key 'missing' is not set", func() {
+		missing.MustGet(ctx)
+	})
+}
+
+func TestContext_SetConcurrently(t *testing.T) {
+	ctx := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := strconv.Itoa(i)
+			ctx.Set(key, i)
+			assert.Equal(t, i, ctx.Get(key))
+		}()
+	}
+	wg.Wait()
+}
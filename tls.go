@@ -0,0 +1,175 @@
+package harmony
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// certCheckInterval is the minimum interval between two checks of the
+// modification times of the certificate and key files.
+var certCheckInterval = time.Second
+
+type (
+	// certReloader loads a certificate and its key, and reloads them when
+	// the files change on disk.
+	certReloader struct {
+		certFile  string
+		keyFile   string
+		errorLog  *log.Logger
+		nextCheck atomic.Int64
+		mu        sync.RWMutex
+		cert      *tls.Certificate
+		certMod   time.Time
+		keyMod    time.Time
+
+		// failedCertMod and failedKeyMod are the modification times of the
+		// files which failed to load, so that they are not loaded again.
+		failedCertMod time.Time
+		failedKeyMod  time.Time
+	}
+)
+
+// ListenAndServeTLS starts the server with TLS on Config.Addr. The certificate
+// and key files are reloaded when they change on disk.
+func (h *Harmony) ListenAndServeTLS(certFile, keyFile string) error {
+	tlsConfig, err := h.tlsConfig(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	l, err := h.listen()
+	if err != nil {
+		return err
+	}
+
+	return h.serve(l, tlsConfig)
+}
+
+// tlsConfig returns a clone of Config.TLSConfig serving the certificate of
+// certFile and keyFile, and verifying client certificates against Config.ClientCAFile.
+func (h *Harmony) tlsConfig(certFile, keyFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if h.config.TLSConfig != nil {
+		tlsConfig = h.config.TLSConfig.Clone()
+	}
+
+	if certFile != "" || keyFile != "" {
+		cr, err := newCertReloader(certFile, keyFile, h.config.ErrorLog)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetCertificate = cr.GetCertificate
+	}
+
+	if h.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(h.config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("harmony: no certificate found in " + h.config.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = h.config.ClientAuth
+		if tlsConfig.ClientAuth == tls.NoClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return tlsConfig, nil
+}
+
+func newCertReloader(certFile, keyFile string, errorLog *log.Logger) (*certReloader, error) {
+	cr := &certReloader{certFile: certFile, keyFile: keyFile, errorLog: errorLog}
+	certInfo, keyInfo, err := cr.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := cr.reload(certInfo, keyInfo); err != nil {
+		return nil, err
+	}
+	cr.nextCheck.Store(time.Now().Add(certCheckInterval).UnixNano())
+	return cr, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. The files are checked
+// at most once per certCheckInterval, and the previous certificate is kept if
+// the files changed but cannot be loaded.
+func (cr *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	now := time.Now()
+	next := cr.nextCheck.Load()
+	if now.UnixNano() >= next && cr.nextCheck.CompareAndSwap(next, now.Add(certCheckInterval).UnixNano()) {
+		if err := cr.reloadIfModified(); err != nil {
+			cr.logf("harmony: failed to reload certificate: %v", err)
+		}
+	}
+
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	return cr.cert, nil
+}
+
+// reloadIfModified reloads the files if they changed since they were loaded
+// or last failed to load.
+func (cr *certReloader) reloadIfModified() error {
+	certInfo, keyInfo, err := cr.stat()
+	if err != nil {
+		return err
+	}
+
+	certMod, keyMod := certInfo.ModTime(), keyInfo.ModTime()
+	cr.mu.RLock()
+	loaded := certMod.Equal(cr.certMod) && keyMod.Equal(cr.keyMod)
+	failed := certMod.Equal(cr.failedCertMod) && keyMod.Equal(cr.failedKeyMod)
+	cr.mu.RUnlock()
+	if loaded || failed {
+		return nil
+	}
+
+	if err := cr.reload(certInfo, keyInfo); err != nil {
+		cr.mu.Lock()
+		cr.failedCertMod, cr.failedKeyMod = certMod, keyMod
+		cr.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (cr *certReloader) stat() (certInfo, keyInfo os.FileInfo, err error) {
+	if certInfo, err = os.Stat(cr.certFile); err != nil {
+		return nil, nil, err
+	}
+	if keyInfo, err = os.Stat(cr.keyFile); err != nil {
+		return nil, nil, err
+	}
+	return certInfo, keyInfo, nil
+}
+
+func (cr *certReloader) reload(certInfo, keyInfo os.FileInfo) error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.cert = &cert
+	cr.certMod = certInfo.ModTime()
+	cr.keyMod = keyInfo.ModTime()
+	return nil
+}
+
+// logf logs to Config.ErrorLog, or to the standard logger if it is nil.
+func (cr *certReloader) logf(format string, args ...any) {
+	if cr.errorLog != nil {
+		cr.errorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,216 @@
+package harmony
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHarmony_TLSReload(t *testing.T) {
+	setCertCheckInterval(t, 0)
+	dir := t.TempDir()
+	ca := newTestCert(t, nil, "ca", 1)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCert(t, newTestCert(t, ca, "127.0.0.1", 2), certFile, keyFile)
+
+	app := New()
+	app.Get("/", writeStringOKHandler())
+	tlsConfig, err := app.tlsConfig(certFile, keyFile)
+	require.NoError(t, err)
+	addr := serveTLS(t, app, tlsConfig)
+
+	assert.Equal(t, int64(2), getPeerSerial(t, newTLSClient(ca, nil), addr))
+
+	writeTestCert(t, newTestCert(t, ca, "127.0.0.1", 3), certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+
+	assert.Equal(t, int64(3), getPeerSerial(t, newTLSClient(ca, nil), addr))
+}
+
+func TestCertReloader_GetCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, nil, "ca", 1)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCert(t, newTestCert(t, ca, "127.0.0.1", 2), certFile, keyFile)
+	buf := &bytes.Buffer{}
+	cr, err := newCertReloader(certFile, keyFile, log.New(buf, "", 0))
+	require.NoError(t, err)
+
+	// The files are not checked again within the interval
+	writeTestCert(t, newTestCert(t, ca, "127.0.0.1", 3), certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+	cert, err := cr.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), certSerial(t, cert))
+
+	setCertCheckInterval(t, 0)
+	cr.nextCheck.Store(0)
+	cert, err = cr.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), certSerial(t, cert))
+
+	// A broken pair is logged once and the previous certificate is kept
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0o600))
+	future = future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+	for i := 0; i < 3; i++ {
+		cert, err = cr.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), certSerial(t, cert))
+	}
+	assert.Equal(t, 1, strings.Count(buf.String(), "harmony: failed to reload certificate"))
+
+	writeTestCert(t, newTestCert(t, ca, "127.0.0.1", 4), certFile, keyFile)
+	future = future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+	cert, err = cr.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), certSerial(t, cert))
+}
+
+func TestHarmony_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, nil, "ca", 1)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCert(t, newTestCert(t, ca, "127.0.0.1", 2), certFile, keyFile)
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600))
+
+	app := New(&Config{ClientCAFile: caFile})
+	app.Get("/", func(ctx Context) error {
+		return ctx.String(http.StatusOK, ctx.PeerCertificate().Subject.CommonName)
+	})
+	tlsConfig, err := app.tlsConfig(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+	addr := serveTLS(t, app, tlsConfig)
+
+	res, err := newTLSClient(ca, newTestCert(t, ca, "client", 4)).Get("https://" + addr)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "client", string(body))
+
+	_, err = newTLSClient(ca, nil).Get("https://" + addr)
+	assert.Error(t, err)
+}
+
+func TestContext_PeerCertificate(t *testing.T) {
+	ctx := newContext(nil, &http.Request{})
+	assert.Nil(t, ctx.PeerCertificate())
+}
+
+func certSerial(t *testing.T, cert *tls.Certificate) int64 {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.SerialNumber.Int64()
+}
+
+// setCertCheckInterval sets certCheckInterval for the duration of the test.
+func setCertCheckInterval(t *testing.T, d time.Duration) {
+	interval := certCheckInterval
+	certCheckInterval = d
+	t.Cleanup(func() {
+		certCheckInterval = interval
+	})
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert returns a certificate for name signed by parent, or a self-signed CA if parent is nil.
+func newTestCert(t *testing.T, parent *testCert, name string, serial int64) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signerCert, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+func writeTestCert(t *testing.T, c *testCert, certFile, keyFile string) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+// serveTLS serves app with tlsConfig on an ephemeral port and returns its address.
+func serveTLS(t *testing.T, app *Harmony, tlsConfig *tls.Config) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := app.newServer(l.Addr().String())
+	srv.TLSConfig = tlsConfig
+	srv.ErrorLog = log.New(io.Discard, "", 0)
+	go func() {
+		_ = srv.ServeTLS(l, "", "")
+	}()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+	return l.Addr().String()
+}
+
+func newTLSClient(ca *testCert, cert *testCert) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	tlsConfig := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if cert != nil {
+		tlsConfig.Certificates = []tls.Certificate{cert.tlsCertificate()}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+}
+
+func getPeerSerial(t *testing.T, client *http.Client, addr string) int64 {
+	res, err := client.Get("https://" + addr)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	return res.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
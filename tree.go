@@ -0,0 +1,278 @@
+package harmony
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	staticKind nodeKind = iota
+	paramKind
+	wildcardKind
+)
+
+type (
+	// radixRouter is the built-in Router backed by a radix tree.
+	radixRouter struct {
+		root *node
+	}
+
+	nodeKind uint8
+
+	node struct {
+		kind      nodeKind
+		prefix    string
+		static    []*node
+		param     *node
+		wildcard  *node
+		endpoints map[string]*endpoint
+		methods   []string
+	}
+
+	endpoint struct {
+		handler HandlerFunc
+		names   []string
+	}
+)
+
+// NewRouter returns the built-in radix tree Router.
+// Static segments take priority over :param segments, which take priority
+// over *wildcard segments.
+func NewRouter() Router {
+	return &radixRouter{root: &node{}}
+}
+
+// Add registers the handler for the method and path.
+func (rr *radixRouter) Add(method, path string, handler HandlerFunc) {
+	if path == "" || path[0] != '/' {
+		panic("harmony: path must begin with '/' in path '" + path + "'")
+	}
+	path = radixPath(path)
+
+	var names []string
+	n := rr.root
+	for i := 0; i < len(path); {
+		switch {
+		case isSegmentStart(path, i, ':'):
+			j := i + 1
+			for j < len(path) && path[j] != '/' {
+				j++
+			}
+			if j == i+1 {
+				panic("harmony: param must be named in path '" + path + "'")
+			}
+			names = append(names, path[i+1:j])
+			if n.param == nil {
+				n.param = &node{kind: paramKind}
+			}
+			n = n.param
+			i = j
+		case isSegmentStart(path, i, '*'):
+			name := path[i+1:]
+			if strings.IndexByte(name, '/') >= 0 {
+				panic("harmony: wildcard must be the last segment in path '" + path + "'")
+			}
+			if name == "" {
+				name = "*"
+			}
+			names = append(names, name)
+			if n.wildcard == nil {
+				n.wildcard = &node{kind: wildcardKind}
+			}
+			n = n.wildcard
+			i = len(path)
+		default:
+			j := i + 1
+			for j < len(path) && !isSegmentStart(path, j, ':') && !isSegmentStart(path, j, '*') {
+				j++
+			}
+			n = n.insert(path[i:j])
+			i = j
+		}
+	}
+
+	if n.endpoints == nil {
+		n.endpoints = make(map[string]*endpoint)
+	}
+	if _, ok := n.endpoints[method]; !ok {
+		n.methods = append(n.methods, method)
+		sort.Strings(n.methods)
+	}
+	n.endpoints[method] = &endpoint{handler: handler, names: names}
+}
+
+// radixPath converts gorilla's {name} and trailing {name:.*} segments into
+// :name and *name. It panics on other gorilla patterns, which the radix tree
+// cannot match.
+func radixPath(path string) string {
+	if !strings.Contains(path, "{") {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if !strings.Contains(s, "{") {
+			continue
+		}
+		var name, pattern string
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			name, pattern, _ = strings.Cut(s[1:len(s)-1], ":")
+		}
+		switch {
+		case name != "" && pattern == "":
+			segments[i] = ":" + name
+		case name != "" && pattern == ".*" && i == len(segments)-1:
+			segments[i] = "*" + name
+		default:
+			panic("harmony: segment '" + s + "' in path '" + path + "' is not supported by the default router, " +
+				"use :name and *name or Config.Router: NewGorillaRouter()")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Find returns the handler registered for the method and path and appends
+// the matched path parameters to params.
+func (rr *radixRouter) Find(method, path string, params *Params) HandlerFunc {
+	start := len(*params)
+	n := rr.root.match(method, path, params)
+	if n == nil {
+		return nil
+	}
+
+	e := n.endpoints[method]
+	for i, name := range e.names {
+		(*params)[start+i].Key = name
+	}
+	return e.handler
+}
+
+// Methods returns the sorted methods registered for the path.
+func (rr *radixRouter) Methods(path string) []string {
+	methods := rr.root.collect(path, nil)
+	sort.Strings(methods)
+
+	uniq := methods[:0]
+	for i, m := range methods {
+		if i == 0 || m != methods[i-1] {
+			uniq = append(uniq, m)
+		}
+	}
+	return uniq
+}
+
+// insert returns the static node for s, splitting existing nodes on their
+// common prefix when needed.
+func (n *node) insert(s string) *node {
+	for _, c := range n.static {
+		if c.prefix[0] != s[0] {
+			continue
+		}
+
+		l := commonPrefixLen(c.prefix, s)
+		if l < len(c.prefix) {
+			child := *c
+			child.prefix = c.prefix[l:]
+			*c = node{kind: staticKind, prefix: c.prefix[:l], static: []*node{&child}}
+		}
+		if l == len(s) {
+			return c
+		}
+		return c.insert(s[l:])
+	}
+
+	c := &node{kind: staticKind, prefix: s}
+	n.static = append(n.static, c)
+	return c
+}
+
+// match returns the node handling method for the rest of the path, backtracking
+// from static to param to wildcard children.
+func (n *node) match(method, path string, params *Params) *node {
+	if path == "" {
+		if n.handles(method) {
+			return n
+		}
+		if w := n.wildcard; w != nil && w.handles(method) {
+			*params = append(*params, Param{})
+			return w
+		}
+		return nil
+	}
+
+	for _, c := range n.static {
+		if c.prefix[0] != path[0] {
+			continue
+		}
+		if strings.HasPrefix(path, c.prefix) {
+			if m := c.match(method, path[len(c.prefix):], params); m != nil {
+				return m
+			}
+		}
+		break
+	}
+
+	if n.param != nil {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			*params = append(*params, Param{Value: path[:end]})
+			if m := n.param.match(method, path[end:], params); m != nil {
+				return m
+			}
+			*params = (*params)[:len(*params)-1]
+		}
+	}
+
+	if w := n.wildcard; w != nil && w.handles(method) {
+		*params = append(*params, Param{Value: path})
+		return w
+	}
+	return nil
+}
+
+// collect appends the methods of every node matching the rest of the path to methods.
+func (n *node) collect(path string, methods []string) []string {
+	if n.wildcard != nil {
+		methods = append(methods, n.wildcard.methods...)
+	}
+	if path == "" {
+		return append(methods, n.methods...)
+	}
+
+	for _, c := range n.static {
+		if strings.HasPrefix(path, c.prefix) {
+			methods = c.collect(path[len(c.prefix):], methods)
+		}
+	}
+
+	if n.param != nil {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			methods = n.param.collect(path[end:], methods)
+		}
+	}
+	return methods
+}
+
+// handles reports whether n has an endpoint for method.
+func (n *node) handles(method string) bool {
+	return n.endpoints[method] != nil
+}
+
+// isSegmentStart reports whether path[i] is c at the beginning of a path segment.
+func isSegmentStart(path string, i int, c byte) bool {
+	return i > 0 && path[i] == c && path[i-1] == '/'
+}
+
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
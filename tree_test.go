@@ -0,0 +1,134 @@
+package harmony
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestRouter_Find(t *testing.T) {
+	r := NewRouter()
+	routes := []string{
+		"/",
+		"/users",
+		"/users/new",
+		"/users/:id",
+		"/users/:id/posts/:post_id",
+		"/users/:id/files/*path",
+		"/static/*",
+		"/search",
+		"/support",
+	}
+	for _, path := range routes {
+		r.Add(http.MethodGet, path, handlerNamed(path))
+	}
+
+	tests := []struct {
+		path   string
+		route  string
+		params Params
+	}{
+		{path: "/", route: "/"},
+		{path: "/users", route: "/users"},
+		{path: "/users/new", route: "/users/new"},
+		{path: "/users/1", route: "/users/:id", params: Params{{Key: "id", Value: "1"}}},
+		{path: "/users/1/posts/2", route: "/users/:id/posts/:post_id", params: Params{{Key: "id", Value: "1"}, {Key: "post_id", Value: "2"}}},
+		{path: "/users/new/posts/2", route: "/users/:id/posts/:post_id", params: Params{{Key: "id", Value: "new"}, {Key: "post_id", Value: "2"}}},
+		{path: "/users/1/files/a/b.txt", route: "/users/:id/files/*path", params: Params{{Key: "id", Value: "1"}, {Key: "path", Value: "a/b.txt"}}},
+		{path: "/static/", route: "/static/*", params: Params{{Key: "*", Value: ""}}},
+		{path: "/static/css/app.css", route: "/static/*", params: Params{{Key: "*", Value: "css/app.css"}}},
+		{path: "/search", route: "/search"},
+		{path: "/support", route: "/support"},
+		{path: "/sup"},
+		{path: "/users/1/posts"},
+		{path: "/users/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var params Params
+			handler := r.Find(http.MethodGet, tt.path, &params)
+			if tt.route == "" {
+				assert.Nil(t, handler)
+				return
+			}
+			if assert.NotNil(t, handler) {
+				assert.Equal(t, tt.route, handlerName(handler))
+				assert.Equal(t, tt.params, params)
+			}
+		})
+	}
+}
+
+func TestRouter_FindMethod(t *testing.T) {
+	r := NewRouter()
+	r.Add(http.MethodGet, "/users/new", handlerNamed("new"))
+	r.Add(http.MethodPost, "/users/:id", handlerNamed("update"))
+
+	var params Params
+	assert.Equal(t, "update", handlerName(r.Find(http.MethodPost, "/users/new", &params)))
+	assert.Equal(t, Params{{Key: "id", Value: "new"}}, params)
+	assert.Nil(t, r.Find(http.MethodDelete, "/users/new", &params))
+}
+
+func TestRouter_Methods(t *testing.T) {
+	r := NewRouter()
+	r.Add(http.MethodGet, "/users/new", handlerNamed(""))
+	r.Add(http.MethodPost, "/users/:id", handlerNamed(""))
+	r.Add(http.MethodPut, "/users/:id", handlerNamed(""))
+	r.Add(http.MethodDelete, "/users/*", handlerNamed(""))
+
+	assert.Equal(t, []string{http.MethodDelete, http.MethodGet, http.MethodPost, http.MethodPut}, r.Methods("/users/new"))
+	assert.Equal(t, []string{http.MethodDelete, http.MethodPost, http.MethodPut}, r.Methods("/users/1"))
+	assert.Equal(t, []string{http.MethodDelete}, r.Methods("/users/1/posts"))
+	assert.Empty(t, r.Methods("/posts"))
+}
+
+func TestRouter_Add(t *testing.T) {
+	r := NewRouter()
+	assert.Panics(t, func() { r.Add(http.MethodGet, "users", handlerNamed("")) })
+	assert.Panics(t, func() { r.Add(http.MethodGet, "/users/:", handlerNamed("")) })
+	assert.Panics(t, func() { r.Add(http.MethodGet, "/files/*path/edit", handlerNamed("")) })
+	assert.Panics(t, func() { r.Add(http.MethodGet, "/posts/{id:[0-9]+}", handlerNamed("")) })
+	assert.Panics(t, func() { r.Add(http.MethodGet, "/files/{path:.*}/edit", handlerNamed("")) })
+	assert.Panics(t, func() { r.Add(http.MethodGet, "/files/report.{ext}", handlerNamed("")) })
+}
+
+func TestRouter_Add_GorillaSyntax(t *testing.T) {
+	r := NewRouter()
+	r.Add(http.MethodGet, "/old/{id}/posts/{post_id}", handlerNamed("post"))
+	r.Add(http.MethodGet, "/files/{path:.*}", handlerNamed("file"))
+
+	var params Params
+	assert.Equal(t, "post", handlerName(r.Find(http.MethodGet, "/old/5/posts/6", &params)))
+	assert.Equal(t, Params{{Key: "id", Value: "5"}, {Key: "post_id", Value: "6"}}, params)
+
+	params = params[:0]
+	assert.Equal(t, "file", handlerName(r.Find(http.MethodGet, "/files/a/b.txt", &params)))
+	assert.Equal(t, Params{{Key: "path", Value: "a/b.txt"}}, params)
+}
+
+func BenchmarkRouter_Find(b *testing.B) {
+	r := NewRouter()
+	r.Add(http.MethodGet, "/users/:id/posts/:post_id", handlerNamed(""))
+	params := make(Params, 0, 2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		params = params[:0]
+		r.Find(http.MethodGet, "/users/1/posts/2", &params)
+	}
+}
+
+// handlerNamed returns a handler which reports name through handlerName.
+func handlerNamed(name string) HandlerFunc {
+	return func(ctx Context) error {
+		return NewHTTPError(http.StatusOK, name)
+	}
+}
+
+func handlerName(handler HandlerFunc) string {
+	if handler == nil {
+		return ""
+	}
+	return handler(nil).(*HTTPError).Message
+}